@@ -0,0 +1,6 @@
+package broadcaster
+
+import "errors"
+
+// ErrClosed is returned if the broadcaster was closed
+var ErrClosed = errors.New("broadcaster closed")
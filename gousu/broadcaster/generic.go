@@ -1,66 +1,291 @@
 package broadcaster
 
-import "sync"
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type consumer[O comparable] struct {
+	channel chan O
+	options *subscribeOptions
+	// done is closed when the consumer is removed, unblocking a pending delivery
+	done   chan struct{}
+	mutex  sync.Mutex
+	closed bool
+}
+
+// deliver sends a value to the consumer regarding its delivery policy
+func (c *consumer[O]) deliver(val O) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	switch c.options.policy {
+	case DeliveryPolicyDropNewest:
+		select {
+		case c.channel <- val:
+		default:
+		}
+	case DeliveryPolicyDropOldest, DeliveryPolicyCoalesce:
+		for {
+			select {
+			case c.channel <- val:
+				return
+			default:
+			}
+
+			select {
+			case <-c.channel:
+			default:
+			}
+		}
+	default:
+		if c.options.timeout <= 0 {
+			select {
+			case c.channel <- val:
+			case <-c.done:
+			}
+
+			return
+		}
+
+		timer := time.NewTimer(c.options.timeout)
+		defer timer.Stop()
+
+		select {
+		case c.channel <- val:
+		case <-c.done:
+		case <-timer.C:
+		}
+	}
+}
+
+// close stops all deliveries to the consumer, must only be called once after
+// the consumer was removed
+func (c *consumer[O]) close(closeChannel bool) {
+	close(c.done)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.closed = true
+
+	if closeChannel {
+		close(c.channel)
+	}
+}
 
 type Generic[O comparable] struct {
-	consumers      map[int64]chan O
+	consumers      map[int64]*consumer[O]
 	nextConsumerID int64
 	mutexConsumers sync.Mutex
-	lastValue      O
+	// mutexNext keeps the order of values passed to concurrent calls of Next
+	mutexNext  sync.Mutex
+	lastValue  O
+	mutexValue sync.RWMutex
+	closed     bool
 }
 
 var _ Base = (*Generic[bool])(nil)
 var _ Base = (*Generic[error])(nil)
 var _ Base = (*Generic[int64])(nil)
 
+// Value returns the last value passed to Next (or the initial value)
 func (b *Generic[O]) Value() O {
+	b.mutexValue.RLock()
+	defer b.mutexValue.RUnlock()
+
 	return b.lastValue
 }
 
+// Next sets the value and delivers it to all subscribers
+//
+// Does nothing after the broadcaster was closed
 func (b *Generic[O]) Next(val O) {
+	b.NextExcept(val, nil)
+}
+
+// NextExcept sets the value and delivers it to all subscribers except the
+// given subscription (e.g. to apply a value received from the subscriber itself)
+//
+// Does nothing after the broadcaster was closed
+func (b *Generic[O]) NextExcept(val O, excluded *Subscription) {
+	b.mutexNext.Lock()
+	defer b.mutexNext.Unlock()
+
 	b.mutexConsumers.Lock()
-	defer b.mutexConsumers.Unlock()
 
+	if b.closed {
+		b.mutexConsumers.Unlock()
+
+		return
+	}
+
+	b.mutexValue.Lock()
 	b.lastValue = val
+	b.mutexValue.Unlock()
 
-	for _, consumer := range b.consumers {
-		consumer <- val
+	excludedID := int64(-1)
+	if excluded != nil && excluded.base == Base(b) {
+		excludedID = excluded.id
+	}
+
+	consumers := make([]*consumer[O], 0, len(b.consumers))
+	for id, consumer := range b.consumers {
+		if id == excludedID {
+			continue
+		}
+
+		consumers = append(consumers, consumer)
+	}
+
+	b.mutexConsumers.Unlock()
+
+	// Delivered without holding mutexConsumers, so subscribers can be removed
+	// while a delivery is blocking
+	for _, consumer := range consumers {
+		consumer.deliver(val)
 	}
 }
 
-func (b *Generic[O]) Subscribe() (chan O, *Subscription) {
+// Subscribe returns a channel receiving all values passed to Next
+//
+// By default Next blocks until the value was delivered to the channel (capacity 1),
+// this can be changed via WithPolicy, WithBufferSize and WithTimeout. WithReplay
+// delivers the current value immediately.
+//
+// A subscriber not reading its channel delays Next for all producers by up to
+// DefaultTimeout per value, subscribers which can't keep up should use a
+// non-blocking policy like DeliveryPolicyDropOldest.
+func (b *Generic[O]) Subscribe(opts ...SubscribeOption) (chan O, *Subscription) {
 	b.mutexConsumers.Lock()
 	defer b.mutexConsumers.Unlock()
 
 	id := b.nextConsumerID
 	b.nextConsumerID++
 
-	consumer := make(chan O, 1)
+	options := newSubscribeOptions(opts...)
 
-	b.consumers[id] = consumer
+	consumer := &consumer[O]{
+		channel: make(chan O, options.bufferSize),
+		options: options,
+		done:    make(chan struct{}),
+	}
 
 	subscription := &Subscription{
 		id:   id,
 		base: b,
 	}
 
-	return consumer, subscription
+	if b.closed {
+		close(consumer.channel)
+
+		return consumer.channel, subscription
+	}
+
+	b.consumers[id] = consumer
+
+	if options.replay {
+		// The new channel always has space for the first value
+		consumer.channel <- b.Value()
+	}
+
+	return consumer.channel, subscription
+}
+
+// SubscribeContext subscribes like Subscribe, but unsubscribes and closes the
+// channel when the context is cancelled
+func (b *Generic[O]) SubscribeContext(ctx context.Context, opts ...SubscribeOption) chan O {
+	channel, subscription := b.Subscribe(opts...)
+
+	go func() {
+		<-ctx.Done()
+
+		b.unsubscribe(subscription.id, true)
+	}()
+
+	return channel
+}
+
+// WaitFor blocks until the value fulfills the predicate and returns it
+//
+// Returns the context's error if it is cancelled before and ErrClosed if
+// the broadcaster gets closed
+func (b *Generic[O]) WaitFor(ctx context.Context, predicate func(val O) bool) (O, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	channel := b.SubscribeContext(ctx, WithPolicy(DeliveryPolicyCoalesce), WithReplay())
+
+	for {
+		select {
+		case val, ok := <-channel:
+			if !ok {
+				var empty O
+
+				if ctx.Err() != nil {
+					return empty, ctx.Err()
+				}
+
+				return empty, ErrClosed
+			}
+
+			if predicate(val) {
+				return val, nil
+			}
+		case <-ctx.Done():
+			var empty O
+
+			return empty, ctx.Err()
+		}
+	}
+}
+
+func (b *Generic[O]) unsubscribe(id int64, closeChannel bool) {
+	b.mutexConsumers.Lock()
+
+	consumer, ok := b.consumers[id]
+	if !ok {
+		b.mutexConsumers.Unlock()
+
+		return
+	}
+
+	delete(b.consumers, id)
+
+	b.mutexConsumers.Unlock()
+
+	consumer.close(closeChannel)
 }
 
 func (b *Generic[O]) Unsubscribe(id int64) {
+	b.unsubscribe(id, false)
+}
+
+// Close closes the channels of all subscribers, so range loops over them terminate
+func (b *Generic[O]) Close() {
 	b.mutexConsumers.Lock()
 	defer b.mutexConsumers.Unlock()
 
-	if _, ok := b.consumers[id]; !ok {
+	if b.closed {
 		return
 	}
 
-	delete(b.consumers, id)
+	b.closed = true
+
+	for id, consumer := range b.consumers {
+		consumer.close(true)
+		delete(b.consumers, id)
+	}
 }
 
 func NewGeneric[O comparable](initialValue O) *Generic[O] {
 	return &Generic[O]{
-		consumers: map[int64]chan O{},
+		consumers: map[int64]*consumer[O]{},
 		lastValue: initialValue,
 	}
 }
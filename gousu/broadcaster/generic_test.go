@@ -0,0 +1,212 @@
+package broadcaster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenericNext(t *testing.T) {
+	b := NewInt(0)
+
+	channel, subscription := b.Subscribe()
+
+	b.Next(1)
+
+	assert.Equal(t, 1, <-channel)
+	assert.Equal(t, 1, b.Value())
+
+	subscription.Unsubscribe()
+
+	b.Next(2)
+
+	assert.Len(t, channel, 0)
+}
+
+func TestGenericNextExcept(t *testing.T) {
+	b := NewInt(0)
+
+	channel, _ := b.Subscribe()
+	excludedChannel, excluded := b.Subscribe()
+
+	b.NextExcept(1, excluded)
+
+	assert.Equal(t, 1, <-channel)
+	assert.Equal(t, 1, b.Value())
+	assert.Len(t, excludedChannel, 0)
+}
+
+func TestGenericDropNewest(t *testing.T) {
+	b := NewInt(0)
+
+	channel, _ := b.Subscribe(WithPolicy(DeliveryPolicyDropNewest), WithBufferSize(2))
+
+	b.Next(1)
+	b.Next(2)
+	b.Next(3)
+
+	assert.Equal(t, 1, <-channel)
+	assert.Equal(t, 2, <-channel)
+	assert.Len(t, channel, 0)
+}
+
+func TestGenericDropOldest(t *testing.T) {
+	b := NewInt(0)
+
+	channel, _ := b.Subscribe(WithPolicy(DeliveryPolicyDropOldest), WithBufferSize(2))
+
+	b.Next(1)
+	b.Next(2)
+	b.Next(3)
+
+	assert.Equal(t, 2, <-channel)
+	assert.Equal(t, 3, <-channel)
+}
+
+func TestGenericCoalesce(t *testing.T) {
+	b := NewBool(false)
+
+	channel, _ := b.Subscribe(WithPolicy(DeliveryPolicyCoalesce), WithBufferSize(10))
+
+	b.Next(true)
+	b.Next(false)
+	b.Next(true)
+
+	assert.Equal(t, 1, cap(channel))
+	assert.Equal(t, true, <-channel)
+	assert.Len(t, channel, 0)
+}
+
+func TestGenericBlockTimeout(t *testing.T) {
+	b := NewInt(0)
+
+	channel, _ := b.Subscribe(WithTimeout(10 * time.Millisecond))
+
+	b.Next(1)
+	b.Next(2)
+
+	assert.Equal(t, 1, <-channel)
+	assert.Equal(t, 2, b.Value())
+
+	// Blocking subscriptions use DefaultTimeout unless set explicitly
+	assert.Equal(t, DefaultTimeout, newSubscribeOptions().timeout)
+	assert.Equal(t, time.Duration(0), newSubscribeOptions(WithTimeout(0)).timeout)
+}
+
+func TestGenericClose(t *testing.T) {
+	b := NewInt(0)
+
+	channel, _ := b.Subscribe()
+
+	done := make(chan struct{})
+
+	go func() {
+		for range channel {
+		}
+
+		close(done)
+	}()
+
+	b.Next(1)
+	b.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("range loop did not terminate")
+	}
+
+	b.Next(2)
+	assert.Equal(t, 1, b.Value())
+
+	channel, _ = b.Subscribe()
+	_, ok := <-channel
+	assert.False(t, ok)
+}
+
+func TestGenericReplay(t *testing.T) {
+	b := NewInt(5)
+
+	channel, _ := b.Subscribe(WithReplay())
+
+	assert.Equal(t, 5, <-channel)
+}
+
+func TestGenericSubscribeContext(t *testing.T) {
+	b := NewInt(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	channel := b.SubscribeContext(ctx, WithPolicy(DeliveryPolicyCoalesce))
+
+	b.Next(1)
+	assert.Equal(t, 1, <-channel)
+
+	cancel()
+
+	_, ok := <-channel
+	assert.False(t, ok)
+
+	b.Next(2)
+}
+
+func TestGenericSubscribeContextBlocked(t *testing.T) {
+	b := NewInt(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The reader of the channel stopped, so Next blocks on the full channel
+	b.SubscribeContext(ctx, WithTimeout(0))
+
+	done := make(chan struct{})
+
+	go func() {
+		b.Next(1)
+		b.Next(2)
+
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Next was not unblocked by cancelling the subscription")
+	}
+}
+
+func TestGenericWaitFor(t *testing.T) {
+	b := NewBool(false)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.Next(true)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	val, err := b.WaitFor(ctx, func(val bool) bool { return val })
+	assert.NoError(t, err)
+	assert.True(t, val)
+
+	// Already fulfilled
+	val, err = b.WaitFor(ctx, func(val bool) bool { return val })
+	assert.NoError(t, err)
+	assert.True(t, val)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = b.WaitFor(ctx, func(val bool) bool { return !val })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	go b.Close()
+
+	_, err = b.WaitFor(context.Background(), func(val bool) bool { return !val })
+	assert.ErrorIs(t, err, ErrClosed)
+}
@@ -0,0 +1,83 @@
+package broadcaster
+
+import "time"
+
+// DeliveryPolicy defines how values are delivered to a subscriber which doesn't
+// read its channel fast enough
+type DeliveryPolicy int
+
+const (
+	// DeliveryPolicyBlock blocks Next until the value was delivered (default),
+	// at most for DefaultTimeout or the duration set via WithTimeout
+	DeliveryPolicyBlock DeliveryPolicy = iota
+	// DeliveryPolicyDropOldest discards the oldest buffered value
+	DeliveryPolicyDropOldest
+	// DeliveryPolicyDropNewest discards the new value
+	DeliveryPolicyDropNewest
+	// DeliveryPolicyCoalesce only keeps the latest value (buffer size 1)
+	DeliveryPolicyCoalesce
+)
+
+// DefaultTimeout is the maximum duration Next blocks for a subscription with
+// DeliveryPolicyBlock if no timeout was set via WithTimeout
+const DefaultTimeout = 5 * time.Second
+
+type subscribeOptions struct {
+	policy     DeliveryPolicy
+	bufferSize int
+	timeout    time.Duration
+	replay     bool
+}
+
+// SubscribeOption configures a subscription
+type SubscribeOption func(options *subscribeOptions)
+
+// WithPolicy sets the delivery policy of a subscription
+func WithPolicy(policy DeliveryPolicy) SubscribeOption {
+	return func(options *subscribeOptions) {
+		options.policy = policy
+	}
+}
+
+// WithBufferSize sets the capacity of a subscription's channel (defaults to 1)
+func WithBufferSize(bufferSize int) SubscribeOption {
+	return func(options *subscribeOptions) {
+		options.bufferSize = bufferSize
+	}
+}
+
+// WithTimeout limits the duration Next blocks for a subscription with
+// DeliveryPolicyBlock, the value is discarded for this subscription after the timeout
+//
+// A timeout <= 0 blocks Next until the value was delivered, so a stopped reader
+// blocks all calls of Next
+func WithTimeout(timeout time.Duration) SubscribeOption {
+	return func(options *subscribeOptions) {
+		options.timeout = timeout
+	}
+}
+
+// WithReplay immediately delivers the current value to the new subscription
+func WithReplay() SubscribeOption {
+	return func(options *subscribeOptions) {
+		options.replay = true
+	}
+}
+
+func newSubscribeOptions(opts ...SubscribeOption) *subscribeOptions {
+	options := &subscribeOptions{
+		policy:     DeliveryPolicyBlock,
+		bufferSize: 1,
+		timeout:    DefaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.bufferSize < 1 || options.policy == DeliveryPolicyCoalesce {
+		options.bufferSize = 1
+	}
+
+	return options
+}
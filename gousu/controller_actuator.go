@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/indece-official/go-gousu/v2/gousu/metrics"
 	"github.com/namsral/flag"
 )
 
@@ -17,6 +18,7 @@ var (
 )
 
 // ActuatorController is a controller running in a separate thread providing an health endpoint
+// and the metrics of metrics.Default in the prometheus format on /metrics
 type ActuatorController struct {
 	services []IService
 	log      *logger.Log
@@ -52,6 +54,15 @@ func (c *ActuatorController) Start() error {
 			fmt.Fprintf(w, "OK")
 		})
 
+		http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+			err := metrics.Default.WritePrometheus(w)
+			if err != nil {
+				c.log.Warnf("Can't write metrics: %s", err)
+			}
+		})
+
 		err := http.ListenAndServe(fmt.Sprintf("%s:%d", *actuatorHost, *actuatorPort), nil)
 		if err != nil {
 			c.error = c.log.ErrorfX("Can't start actuator server: %s", err)
@@ -0,0 +1,35 @@
+package eventbus
+
+// DefaultQueueSize is the queue capacity of asynchronous subscriptions
+const DefaultQueueSize = 64
+
+type subscribeOptions struct {
+	async     bool
+	queueSize int
+}
+
+// SubscribeOption configures a subscription
+type SubscribeOption func(options *subscribeOptions)
+
+// Async calls the handler in a separate goroutine with a queue of the given
+// size (DefaultQueueSize if < 1)
+func Async(queueSize int) SubscribeOption {
+	return func(options *subscribeOptions) {
+		options.async = true
+		options.queueSize = queueSize
+	}
+}
+
+func newSubscribeOptions(opts ...SubscribeOption) *subscribeOptions {
+	options := &subscribeOptions{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.queueSize < 1 {
+		options.queueSize = DefaultQueueSize
+	}
+
+	return options
+}
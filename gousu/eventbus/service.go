@@ -0,0 +1,380 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/indece-official/go-gousu/v2/gousu"
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+)
+
+// ServiceName defines the name of the event bus service used for dependency injection
+const ServiceName = "eventbus"
+
+// ErrStopped is returned when publishing to a stopped event bus
+var ErrStopped = errors.New("event bus stopped")
+
+// Event is passed to the handlers of all subscriptions matching its topic
+type Event struct {
+	Topic   string
+	Payload interface{}
+	Time    time.Time
+}
+
+// Handler processes an event
+//
+// Errors of synchronous handlers are returned from Publish, errors of all
+// handlers are passed to the error handler of the service
+type Handler func(ctx context.Context, event *Event) error
+
+// ErrorHandler is called for each failed handler
+type ErrorHandler func(subscription *Subscription, event *Event, err error)
+
+// IService defines the interface of the event bus service
+type IService interface {
+	gousu.IService
+
+	Publish(ctx context.Context, topic string, payload interface{}) error
+	Subscribe(pattern string, handler Handler, opts ...SubscribeOption) (*Subscription, error)
+	Unsubscribe(subscription *Subscription)
+	SetErrorHandler(errorHandler ErrorHandler)
+	Stats() *Stats
+}
+
+type asyncEvent struct {
+	ctx   context.Context
+	event *Event
+}
+
+// Subscription is a handler registered for a topic pattern
+type Subscription struct {
+	id        int64
+	pattern   string
+	handler   Handler
+	options   *subscribeOptions
+	queue     chan *asyncEvent
+	delivered atomic.Int64
+	failed    atomic.Int64
+	done      chan struct{}
+	// closing is closed before the queue, unblocking pending Publish calls
+	closing     chan struct{}
+	mutexQueue  sync.RWMutex
+	queueClosed bool
+}
+
+// enqueue queues an event for an asynchronous handler, blocking while the
+// queue is full until the context is cancelled
+//
+// Events are discarded if the queue gets closed
+func (s *Subscription) enqueue(ctx context.Context, event *Event) error {
+	s.mutexQueue.RLock()
+	defer s.mutexQueue.RUnlock()
+
+	if s.queueClosed {
+		return nil
+	}
+
+	select {
+	case s.queue <- &asyncEvent{ctx: context.WithoutCancel(ctx), event: event}:
+		return nil
+	case <-s.closing:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeQueue closes the queue of an asynchronous handler, must only be called once
+func (s *Subscription) closeQueue() {
+	close(s.closing)
+
+	s.mutexQueue.Lock()
+	defer s.mutexQueue.Unlock()
+
+	s.queueClosed = true
+
+	close(s.queue)
+}
+
+// Pattern returns the topic pattern of the subscription
+func (s *Subscription) Pattern() string {
+	return s.pattern
+}
+
+// SubscriptionStats contains the metrics of a subscription
+type SubscriptionStats struct {
+	Pattern       string
+	Async         bool
+	QueueDepth    int
+	QueueCapacity int
+	Delivered     int64
+	Failed        int64
+}
+
+// Stats contains the metrics of the event bus
+type Stats struct {
+	Published     int64
+	Subscriptions []*SubscriptionStats
+}
+
+// Service provides an in-process publish/subscribe event bus
+type Service struct {
+	log                *logger.Log
+	subscriptions      map[int64]*Subscription
+	nextSubscriptionID int64
+	mutex              sync.RWMutex
+	errorHandler       ErrorHandler
+	published          atomic.Int64
+	stopped            bool
+	workers            sync.WaitGroup
+}
+
+var _ IService = (*Service)(nil)
+
+// Name returns the name of the event bus service from ServiceName
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// Start currently does nothing
+func (s *Service) Start() error {
+	return nil
+}
+
+// Health currently always returns nil
+func (s *Service) Health() error {
+	return nil
+}
+
+// Stop rejects new events and waits until all asynchronous handlers have
+// processed their queued events
+func (s *Service) Stop() error {
+	s.mutex.Lock()
+
+	if s.stopped {
+		s.mutex.Unlock()
+
+		return nil
+	}
+
+	s.stopped = true
+
+	for _, subscription := range s.subscriptions {
+		if subscription.queue != nil {
+			subscription.closeQueue()
+		}
+	}
+
+	s.mutex.Unlock()
+
+	s.workers.Wait()
+
+	return nil
+}
+
+// SetErrorHandler replaces the default error handler, which logs all errors with
+// the log fields of the event's context (nil restores the default)
+func (s *Service) SetErrorHandler(errorHandler ErrorHandler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.errorHandler = errorHandler
+}
+
+func (s *Service) handle(ctx context.Context, subscription *Subscription, event *Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+
+		if err != nil {
+			subscription.failed.Add(1)
+
+			s.mutex.RLock()
+			errorHandler := s.errorHandler
+			s.mutex.RUnlock()
+
+			if errorHandler == nil {
+				s.log.ContextX(ctx).Errorf("Error handling event '%s' for '%s': %s", event.Topic, subscription.pattern, err)
+
+				return
+			}
+
+			errorHandler(subscription, event, err)
+
+			return
+		}
+
+		subscription.delivered.Add(1)
+	}()
+
+	return subscription.handler(ctx, event)
+}
+
+func (s *Service) runWorker(subscription *Subscription) {
+	defer s.workers.Done()
+	defer close(subscription.done)
+
+	for asyncEvent := range subscription.queue {
+		s.handle(asyncEvent.ctx, subscription, asyncEvent.event)
+	}
+}
+
+// Publish delivers an event to all subscriptions matching the topic
+//
+// Synchronous handlers are called before Publish returns, their errors are
+// returned joined. Events for asynchronous handlers are queued, Publish blocks if
+// a queue is full until the context is cancelled.
+func (s *Service) Publish(ctx context.Context, topic string, payload interface{}) error {
+	err := validateTopic(topic)
+	if err != nil {
+		return err
+	}
+
+	event := &Event{
+		Topic:   topic,
+		Payload: payload,
+		Time:    time.Now(),
+	}
+
+	s.mutex.RLock()
+
+	if s.stopped {
+		s.mutex.RUnlock()
+
+		return ErrStopped
+	}
+
+	s.published.Add(1)
+
+	subscriptions := []*Subscription{}
+
+	for _, subscription := range s.subscriptions {
+		if matchTopic(subscription.pattern, topic) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	s.mutex.RUnlock()
+
+	// Handlers are called without holding the lock, so they can subscribe,
+	// unsubscribe and publish themselves
+	errs := []error{}
+
+	for _, subscription := range subscriptions {
+		if subscription.queue == nil {
+			err = s.handle(ctx, subscription, event)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("handler for '%s' failed: %w", subscription.pattern, err))
+			}
+
+			continue
+		}
+
+		err = subscription.enqueue(ctx, event)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("can't queue event for '%s': %w", subscription.pattern, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Subscribe registers a handler for all events with a topic matching the pattern
+//
+// Patterns can contain the wildcards "*" (one segment) and ">" (one or more
+// trailing segments), e.g. "user.*" or "cache.>". Handlers are called synchronously
+// unless the option Async is used.
+func (s *Service) Subscribe(pattern string, handler Handler, opts ...SubscribeOption) (*Subscription, error) {
+	err := validatePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stopped {
+		return nil, ErrStopped
+	}
+
+	options := newSubscribeOptions(opts...)
+
+	subscription := &Subscription{
+		id:      s.nextSubscriptionID,
+		pattern: pattern,
+		handler: handler,
+		options: options,
+	}
+
+	s.nextSubscriptionID++
+
+	if options.async {
+		subscription.queue = make(chan *asyncEvent, options.queueSize)
+		subscription.done = make(chan struct{})
+		subscription.closing = make(chan struct{})
+
+		s.workers.Add(1)
+		go s.runWorker(subscription)
+	}
+
+	s.subscriptions[subscription.id] = subscription
+
+	return subscription, nil
+}
+
+// Unsubscribe removes a subscription, queued events of asynchronous handlers
+// are still processed
+func (s *Service) Unsubscribe(subscription *Subscription) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.subscriptions[subscription.id]; !ok {
+		return
+	}
+
+	delete(s.subscriptions, subscription.id)
+
+	if subscription.queue != nil && !s.stopped {
+		subscription.closeQueue()
+	}
+}
+
+// Stats returns the current metrics of the event bus
+func (s *Service) Stats() *Stats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	stats := &Stats{
+		Published:     s.published.Load(),
+		Subscriptions: []*SubscriptionStats{},
+	}
+
+	for _, subscription := range s.subscriptions {
+		stats.Subscriptions = append(stats.Subscriptions, &SubscriptionStats{
+			Pattern:       subscription.pattern,
+			Async:         subscription.queue != nil,
+			QueueDepth:    len(subscription.queue),
+			QueueCapacity: cap(subscription.queue),
+			Delivered:     subscription.delivered.Load(),
+			Failed:        subscription.failed.Load(),
+		})
+	}
+
+	return stats
+}
+
+// NewService is the ServiceFactory for the event bus service
+func NewService(ctx gousu.IContext) gousu.IService {
+	return &Service{
+		log:           logger.GetLogger(fmt.Sprintf("service.%s", ServiceName)),
+		subscriptions: map[int64]*Subscription{},
+	}
+}
+
+// Assert NewService fullfills gousu.ServiceFactory
+var _ (gousu.ServiceFactory) = NewService
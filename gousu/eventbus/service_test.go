@@ -0,0 +1,194 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService() *Service {
+	return NewService(nil).(*Service)
+}
+
+func TestMatchTopic(t *testing.T) {
+	assert.True(t, matchTopic("user.created", "user.created"))
+	assert.False(t, matchTopic("user.created", "user.deleted"))
+	assert.True(t, matchTopic("user.*", "user.deleted"))
+	assert.False(t, matchTopic("user.*", "user.deleted.soft"))
+	assert.True(t, matchTopic("user.>", "user.deleted.soft"))
+	assert.False(t, matchTopic("user.>", "user"))
+	assert.False(t, matchTopic("user.created", "user"))
+	assert.True(t, matchTopic("*.created", "cache.created"))
+}
+
+func TestValidatePattern(t *testing.T) {
+	assert.NoError(t, validatePattern("cache.>"))
+	assert.Error(t, validatePattern("cache.>.entry"))
+	assert.Error(t, validatePattern("cache..entry"))
+	assert.Error(t, validateTopic("cache.*"))
+}
+
+func TestServiceSync(t *testing.T) {
+	s := newTestService()
+
+	received := []string{}
+
+	_, err := Subscribe(s, "user.*", func(ctx context.Context, topic string, payload int) error {
+		received = append(received, topic)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, Publish(s, context.Background(), "user.created", 1))
+	require.NoError(t, Publish(s, context.Background(), "cache.invalidated", 2))
+
+	assert.Equal(t, []string{"user.created"}, received)
+	assert.Equal(t, int64(2), s.Stats().Published)
+	assert.Equal(t, int64(1), s.Stats().Subscriptions[0].Delivered)
+}
+
+func TestServiceErrors(t *testing.T) {
+	s := newTestService()
+
+	reported := []error{}
+
+	s.SetErrorHandler(func(subscription *Subscription, event *Event, err error) {
+		reported = append(reported, err)
+	})
+
+	errFailed := errors.New("failed")
+
+	_, err := s.Subscribe("user.created", func(ctx context.Context, event *Event) error {
+		return errFailed
+	})
+	require.NoError(t, err)
+
+	_, err = Subscribe(s, "user.created", func(ctx context.Context, topic string, payload string) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = s.Publish(context.Background(), "user.created", 1)
+	assert.ErrorIs(t, err, errFailed)
+
+	var payloadTypeError *PayloadTypeError
+	assert.ErrorAs(t, err, &payloadTypeError)
+	assert.Len(t, reported, 2)
+}
+
+func TestServicePanic(t *testing.T) {
+	s := newTestService()
+	s.SetErrorHandler(func(subscription *Subscription, event *Event, err error) {})
+
+	_, err := s.Subscribe("user.created", func(ctx context.Context, event *Event) error {
+		panic("boom")
+	})
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, s.Publish(context.Background(), "user.created", nil), "boom")
+}
+
+func TestServiceAsync(t *testing.T) {
+	s := newTestService()
+
+	release := make(chan struct{})
+
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+
+	_, err := Subscribe(s, "cache.>", func(ctx context.Context, topic string, payload string) error {
+		<-release
+		wg.Done()
+
+		return nil
+	}, Async(4))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, Publish(s, context.Background(), "cache.entry.invalidated", "key"))
+	}
+
+	stats := s.Stats().Subscriptions[0]
+	assert.True(t, stats.Async)
+	assert.Equal(t, 4, stats.QueueCapacity)
+	assert.GreaterOrEqual(t, stats.QueueDepth, 2)
+
+	close(release)
+	wg.Wait()
+
+	require.NoError(t, s.Stop())
+	assert.Equal(t, int64(3), s.Stats().Subscriptions[0].Delivered)
+	assert.ErrorIs(t, s.Publish(context.Background(), "cache.entry", "key"), ErrStopped)
+}
+
+func TestServiceAsyncFullQueue(t *testing.T) {
+	s := newTestService()
+
+	release := make(chan struct{})
+
+	subscription, err := s.Subscribe("cache.>", func(ctx context.Context, event *Event) error {
+		<-release
+
+		return nil
+	}, Async(1))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var lastErr error
+
+	for i := 0; i < 3 && lastErr == nil; i++ {
+		lastErr = s.Publish(ctx, "cache.entry", i)
+	}
+
+	assert.ErrorIs(t, lastErr, context.DeadlineExceeded)
+
+	close(release)
+	s.Unsubscribe(subscription)
+	require.NoError(t, s.Stop())
+	assert.Len(t, s.Stats().Subscriptions, 0)
+}
+
+func TestServiceNestedHandler(t *testing.T) {
+	s := newTestService()
+
+	received := []string{}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		_, err := s.Subscribe("user.created", func(ctx context.Context, event *Event) error {
+			// Subscribing and publishing from within a handler must not deadlock
+			_, err := s.Subscribe("user.welcomed", func(ctx context.Context, event *Event) error {
+				received = append(received, event.Topic)
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			return s.Publish(ctx, "user.welcomed", event.Payload)
+		})
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Publish(context.Background(), "user.created", 1))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handler subscribing from within a handler deadlocked")
+	}
+
+	assert.Equal(t, []string{"user.welcomed"}, received)
+	assert.Len(t, s.Stats().Subscriptions, 2)
+}
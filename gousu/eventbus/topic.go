@@ -0,0 +1,77 @@
+package eventbus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Wildcards usable in subscription patterns
+const (
+	// WildcardSegment matches exactly one segment of a topic
+	WildcardSegment = "*"
+	// WildcardTail matches one or more trailing segments of a topic
+	WildcardTail = ">"
+)
+
+// TopicSeparator separates the segments of a topic (e.g. "user.created")
+const TopicSeparator = "."
+
+func validateTopic(topic string) error {
+	if topic == "" {
+		return fmt.Errorf("empty topic")
+	}
+
+	for _, segment := range strings.Split(topic, TopicSeparator) {
+		if segment == "" {
+			return fmt.Errorf("empty segment in topic '%s'", topic)
+		}
+
+		if segment == WildcardSegment || segment == WildcardTail {
+			return fmt.Errorf("wildcard in topic '%s'", topic)
+		}
+	}
+
+	return nil
+}
+
+func validatePattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("empty pattern")
+	}
+
+	segments := strings.Split(pattern, TopicSeparator)
+
+	for i, segment := range segments {
+		if segment == "" {
+			return fmt.Errorf("empty segment in pattern '%s'", pattern)
+		}
+
+		if segment == WildcardTail && i != len(segments)-1 {
+			return fmt.Errorf("wildcard '%s' must be the last segment in pattern '%s'", WildcardTail, pattern)
+		}
+	}
+
+	return nil
+}
+
+// matchTopic checks if a topic matches a subscription pattern
+func matchTopic(pattern string, topic string) bool {
+	patternSegments := strings.Split(pattern, TopicSeparator)
+	topicSegments := strings.Split(topic, TopicSeparator)
+
+	for i, patternSegment := range patternSegments {
+		if patternSegment == WildcardTail {
+			return len(topicSegments) > i
+		}
+
+		if i >= len(topicSegments) {
+			return false
+		}
+
+		if patternSegment != WildcardSegment && patternSegment != topicSegments[i] {
+			return false
+		}
+	}
+
+	return len(patternSegments) == len(topicSegments)
+}
@@ -0,0 +1,50 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+)
+
+// TypedHandler processes the payload of an event of type T
+type TypedHandler[T any] func(ctx context.Context, topic string, payload T) error
+
+// PayloadTypeError is returned to the error handler if a typed subscription
+// receives a payload of another type
+type PayloadTypeError struct {
+	Topic    string
+	Expected string
+	Actual   string
+}
+
+func (e *PayloadTypeError) Error() string {
+	return fmt.Sprintf("payload of event '%s' has type %s, expected %s", e.Topic, e.Actual, e.Expected)
+}
+
+// Publish publishes a payload of type T to a topic
+func Publish[T any](bus IService, ctx context.Context, topic string, payload T) error {
+	return bus.Publish(ctx, topic, payload)
+}
+
+// Subscribe registers a handler for events with payloads of type T
+//
+// Events with payloads of another type are reported as PayloadTypeError
+func Subscribe[T any](bus IService, pattern string, handler TypedHandler[T], opts ...SubscribeOption) (*Subscription, error) {
+	return bus.Subscribe(
+		pattern,
+		func(ctx context.Context, event *Event) error {
+			payload, ok := event.Payload.(T)
+			if !ok {
+				var expected T
+
+				return &PayloadTypeError{
+					Topic:    event.Topic,
+					Expected: fmt.Sprintf("%T", expected),
+					Actual:   fmt.Sprintf("%T", event.Payload),
+				}
+			}
+
+			return handler(ctx, event.Topic, payload)
+		},
+		opts...,
+	)
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+type contextKey string
+
+const contextKeyFields contextKey = "logger_fields"
+
+// Fields is a set of records attached to a context and added to all logs
+// emitted for this context
+type Fields map[string]interface{}
+
+// contextFields holds the fields of a context, fields can be added in place via
+// AddToContext
+type contextFields struct {
+	mutex  sync.RWMutex
+	fields Fields
+}
+
+// WithContext returns a new context containing the fields of the parent
+// context merged with the given fields
+//
+// Existing fields with the same key are overwritten, the parent context
+// is not modified
+func WithContext(ctx context.Context, fields Fields) context.Context {
+	merged := FromContext(ctx)
+
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	return context.WithValue(ctx, contextKeyFields, &contextFields{fields: merged})
+}
+
+// AddToContext adds fields in place to the fields attached to the context via
+// WithContext, they are visible to all contexts derived from it
+//
+// Returns false if the context contains no fields, use WithContext then
+func AddToContext(ctx context.Context, fields Fields) bool {
+	if ctx == nil {
+		return false
+	}
+
+	holder, ok := ctx.Value(contextKeyFields).(*contextFields)
+	if !ok || holder == nil {
+		return false
+	}
+
+	holder.mutex.Lock()
+	defer holder.mutex.Unlock()
+
+	for key, value := range fields {
+		holder.fields[key] = value
+	}
+
+	return true
+}
+
+// FromContext returns a copy of the fields attached to a context via WithContext
+//
+// Returns an empty set of fields if the context contains no fields
+func FromContext(ctx context.Context) Fields {
+	fields := Fields{}
+
+	if ctx == nil {
+		return fields
+	}
+
+	holder, ok := ctx.Value(contextKeyFields).(*contextFields)
+	if !ok || holder == nil {
+		return fields
+	}
+
+	holder.mutex.RLock()
+	defer holder.mutex.RUnlock()
+
+	for key, value := range holder.fields {
+		fields[key] = value
+	}
+
+	return fields
+}
+
+// ContextX returns a new Logger with all fields attached to the context assigned
+func (l *Log) ContextX(ctx context.Context) *Log {
+	log := l
+
+	for key, value := range FromContext(ctx) {
+		log = log.RecordX(key, value)
+	}
+
+	return log
+}
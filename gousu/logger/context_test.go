@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContextEmpty(t *testing.T) {
+	assert.Equal(t, Fields{}, FromContext(context.Background()))
+}
+
+func TestWithContext(t *testing.T) {
+	ctx0 := WithContext(context.Background(), Fields{"request_id": "abc", "user_id": 1})
+	ctx1 := WithContext(ctx0, Fields{"user_id": 2, "trace_id": "xyz"})
+
+	assert.Equal(t, Fields{"request_id": "abc", "user_id": 1}, FromContext(ctx0))
+	assert.Equal(t, Fields{"request_id": "abc", "user_id": 2, "trace_id": "xyz"}, FromContext(ctx1))
+}
+
+func TestAddToContext(t *testing.T) {
+	assert.False(t, AddToContext(context.Background(), Fields{"user_id": 1}))
+
+	ctx0 := WithContext(context.Background(), Fields{"request_id": "abc"})
+	ctx1, cancel := context.WithCancel(ctx0)
+	defer cancel()
+
+	assert.True(t, AddToContext(ctx1, Fields{"user_id": 1}))
+
+	assert.Equal(t, Fields{"request_id": "abc", "user_id": 1}, FromContext(ctx0))
+	assert.Equal(t, Fields{"request_id": "abc", "user_id": 1}, FromContext(ctx1))
+}
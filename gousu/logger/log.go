@@ -3,6 +3,7 @@ package logger
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/chakrit/go-bunyan"
 	"github.com/indece-official/go-gousu/v2/gousu/siem"
@@ -30,17 +31,74 @@ var (
 // Log provides the base structure for a extended logger
 //
 // The loglevel can be controller via the config property "loglevel" or the environment
-// variable LOGLEVEL
+// variable LOGLEVEL. Messages can be rate limited per message template via SetSampling
+// and SetComponentSampling, SIEM events and fatal messages are never sampled.
+// Sensitive values are masked before being written (see AddRedactionKey and
+// AddRedactionPattern).
 type Log struct {
 	bunyan.Log
+	component string
 }
 
-// SiemEvent logs an siem event
+// sample checks if a message may be logged regarding the sampling configured
+// for the logger's component and logs a summary of suppressed messages
+func (l *Log) sample(level bunyan.Level, msg string) bool {
+	sampler := getSampler(l.component)
+	if sampler == nil {
+		return true
+	}
+
+	allowed, suppressed := sampler.check(l.Log, level, msg, time.Now())
+	if suppressed > 0 {
+		logSamplingSummary(l.Log, msg, suppressed, sampler.config.Interval)
+	}
+
+	return allowed
+}
+
+// Tracef logs a message with level TRACE if not suppressed by sampling
+func (l *Log) Tracef(msg string, args ...interface{}) {
+	if l.sample(bunyan.TRACE, msg) {
+		l.Log.Tracef(msg, args...)
+	}
+}
+
+// Debugf logs a message with level DEBUG if not suppressed by sampling
+func (l *Log) Debugf(msg string, args ...interface{}) {
+	if l.sample(bunyan.DEBUG, msg) {
+		l.Log.Debugf(msg, args...)
+	}
+}
+
+// Infof logs a message with level INFO if not suppressed by sampling
+func (l *Log) Infof(msg string, args ...interface{}) {
+	if l.sample(bunyan.INFO, msg) {
+		l.Log.Infof(msg, args...)
+	}
+}
+
+// Warnf logs a message with level WARN if not suppressed by sampling
+func (l *Log) Warnf(msg string, args ...interface{}) {
+	if l.sample(bunyan.WARN, msg) {
+		l.Log.Warnf(msg, args...)
+	}
+}
+
+// Errorf logs a message with level ERROR if not suppressed by sampling
+func (l *Log) Errorf(msg string, args ...interface{}) {
+	if l.sample(bunyan.ERROR, msg) {
+		l.Log.Errorf(msg, args...)
+	}
+}
+
+// SiemEvent logs an siem event and writes it to all registered siem sinks
 func (l *Log) SiemEvent(event *siem.Event, msg string, args ...interface{}) {
 	if !*siemEnabled {
 		return
 	}
 
+	l.writeSiemSinks(event, msg, args...)
+
 	log := l.Log.
 		Record(siem.EventFieldType, event.Type).
 		Record(siem.EventFieldLevel, event.Level())
@@ -57,6 +115,31 @@ func (l *Log) SiemEvent(event *siem.Event, msg string, args ...interface{}) {
 		log = log.Record(siem.EventFieldSourceRealIP, event.SourceRealIP)
 	}
 
+	if event.TargetResource.Valid {
+		log = log.Record(siem.EventFieldTargetResource, event.TargetResource)
+	}
+
+	if event.Outcome.Valid {
+		log = log.Record(siem.EventFieldOutcome, event.Outcome)
+	}
+
+	if event.Reason.Valid {
+		log = log.Record(siem.EventFieldReason, event.Reason)
+	}
+
+	if event.SessionID.Valid {
+		log = log.Record(siem.EventFieldSessionID, event.SessionID)
+	}
+
+	if event.UserAgent.Valid {
+		log = log.Record(siem.EventFieldUserAgent, event.UserAgent)
+	}
+
+	err := event.Validate()
+	if err != nil {
+		l.Log.Warnf("Invalid SIEM event: %s", err)
+	}
+
 	switch event.Level() {
 	case siem.EventLevelInfo:
 		log.Infof(msg, args...)
@@ -67,11 +150,13 @@ func (l *Log) SiemEvent(event *siem.Event, msg string, args ...interface{}) {
 	default:
 		log.Errorf(msg, args...)
 	}
+
+	l.detectSiemAttacks(event)
 }
 
 // ErrorfX logs an error and returns it
 func (l *Log) ErrorfX(msg string, args ...interface{}) error {
-	l.Log.Errorf(msg, args...)
+	l.Errorf(msg, args...)
 
 	return fmt.Errorf(msg, args...)
 }
@@ -79,14 +164,16 @@ func (l *Log) ErrorfX(msg string, args ...interface{}) error {
 // RecordX returns a new Logger with a specified Record assigned
 func (l *Log) RecordX(key string, value interface{}) *Log {
 	return &Log{
-		l.Log.Record(key, value),
+		Log:       l.Log.Record(key, value),
+		component: l.component,
 	}
 }
 
 // RecordfX returns a new Logger with a specified formatted Record assigned
 func (l *Log) RecordfX(key, value string, args ...interface{}) *Log {
 	return &Log{
-		l.Log.Recordf(key, value, args...),
+		Log:       l.Log.Recordf(key, value, args...),
+		component: l.component,
 	}
 }
 
@@ -108,22 +195,39 @@ func InitLogger(projectName string) {
 
 	if logDisabled {
 		sink = bunyan.NilSink()
+	} else if *logRedactionEnabled {
+		sink = bunyan.FilterSink(level, newRedactionSink(bunyan.StdoutSink()))
 	} else {
 		sink = bunyan.FilterSink(level, bunyan.StdoutSink())
 	}
 
-	parentLogger = &Log{bunyan.NewStdLogger(projectName, sink)}
+	parentLogger = &Log{Log: bunyan.NewStdLogger(projectName, sink)}
+
+	initSampling()
+	initRedaction()
+	initSiemSinks(GetLogger("siem"), projectName)
 
 	if !*siemEnabled {
 		parentLogger.Warnf("SIEM-Event logging is disabled")
 	}
 }
 
+// Close logs the pending summaries of suppressed messages (see SetSampling) and
+// flushes and closes all siem sinks
+func Close() error {
+	closeSampling()
+
+	return CloseSiemSinks()
+}
+
 // GetLogger returns a logger for a specific component
 func GetLogger(componentName string) *Log {
 	if parentLogger == nil {
 		InitLogger("test")
 	}
 
-	return &Log{parentLogger.Record("component", componentName)}
+	return &Log{
+		Log:       parentLogger.Record("component", componentName),
+		component: componentName,
+	}
 }
@@ -0,0 +1,197 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/chakrit/go-bunyan"
+	"github.com/namsral/flag"
+	"gopkg.in/guregu/null.v4"
+)
+
+var (
+	logRedactionEnabled  = flag.Bool("log_redaction_enabled", true, "")
+	logRedactionKeys     = flag.String("log_redaction_keys", "password,passwd,secret,token,authorization,cookie", "")
+	logRedactionPatterns = flag.String("log_redaction_patterns", "jwt,bearer,password,email", "")
+)
+
+// RedactedValue replaces the values of redacted record keys
+const RedactedValue = "[REDACTED]"
+
+// RedactionPattern defines a pattern masked in all logged strings
+type RedactionPattern struct {
+	Name        string
+	Regexp      *regexp.Regexp
+	Replacement string
+}
+
+// Builtin redaction patterns, can be enabled via the config property "log_redaction_patterns"
+var (
+	RedactionPatternJWT = &RedactionPattern{
+		Name:        "jwt",
+		Regexp:      regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`),
+		Replacement: "[REDACTED_JWT]",
+	}
+	RedactionPatternBearer = &RedactionPattern{
+		Name:        "bearer",
+		Regexp:      regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`),
+		Replacement: "${1}" + RedactedValue,
+	}
+	RedactionPatternPassword = &RedactionPattern{
+		Name:        "password",
+		Regexp:      regexp.MustCompile(`(?i)(password|passwd|pwd)(\s*[=:]\s*)[^\s&,;]+`),
+		Replacement: "${1}${2}" + RedactedValue,
+	}
+	RedactionPatternEmail = &RedactionPattern{
+		Name:        "email",
+		Regexp:      regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		Replacement: "[REDACTED_EMAIL]",
+	}
+)
+
+var builtinRedactionPatterns = []*RedactionPattern{
+	RedactionPatternJWT,
+	RedactionPatternBearer,
+	RedactionPatternPassword,
+	RedactionPatternEmail,
+}
+
+// Record keys added by the logger itself, which are never redacted
+var unredactedKeys = map[string]bool{
+	"name":      true,
+	"hostname":  true,
+	"pid":       true,
+	"time":      true,
+	"v":         true,
+	"level":     true,
+	"component": true,
+}
+
+var (
+	redactionKeys     = map[string]bool{}
+	redactionPatterns = []*RedactionPattern{}
+	mutexRedaction    sync.RWMutex
+)
+
+// AddRedactionKey masks the values of all records with the given key (case insensitive)
+func AddRedactionKey(key string) {
+	mutexRedaction.Lock()
+	defer mutexRedaction.Unlock()
+
+	redactionKeys[strings.ToLower(key)] = true
+}
+
+// AddRedactionPattern masks all matches of the pattern in logged messages and
+// string records
+func AddRedactionPattern(pattern *RedactionPattern) {
+	mutexRedaction.Lock()
+	defer mutexRedaction.Unlock()
+
+	redactionPatterns = append(redactionPatterns, pattern)
+}
+
+// ResetRedaction removes all redaction keys and patterns
+func ResetRedaction() {
+	mutexRedaction.Lock()
+	defer mutexRedaction.Unlock()
+
+	redactionKeys = map[string]bool{}
+	redactionPatterns = []*RedactionPattern{}
+}
+
+// Redact masks all matches of the configured redaction patterns in a string
+func Redact(str string) string {
+	mutexRedaction.RLock()
+	defer mutexRedaction.RUnlock()
+
+	return redactString(str)
+}
+
+func redactString(str string) string {
+	for _, pattern := range redactionPatterns {
+		str = pattern.Regexp.ReplaceAllString(str, pattern.Replacement)
+	}
+
+	return str
+}
+
+func redactValue(value interface{}) interface{} {
+	var str string
+
+	switch typedValue := value.(type) {
+	case string:
+		str = typedValue
+	case null.String:
+		if !typedValue.Valid {
+			return value
+		}
+
+		str = typedValue.String
+	case error:
+		str = typedValue.Error()
+	case fmt.Stringer:
+		str = typedValue.String()
+	default:
+		return value
+	}
+
+	redacted := redactString(str)
+	if redacted == str {
+		return value
+	}
+
+	return redacted
+}
+
+// redactRecord masks all configured keys and patterns in a record
+func redactRecord(record bunyan.Record) {
+	mutexRedaction.RLock()
+	defer mutexRedaction.RUnlock()
+
+	for key, value := range record {
+		if unredactedKeys[key] {
+			continue
+		}
+
+		if redactionKeys[strings.ToLower(key)] {
+			record[key] = RedactedValue
+
+			continue
+		}
+
+		record[key] = redactValue(value)
+	}
+}
+
+func newRedactionSink(target bunyan.Sink) bunyan.Sink {
+	return bunyan.SinkFunc(func(record bunyan.Record) error {
+		redactRecord(record)
+
+		return target.Write(record)
+	})
+}
+
+func initRedaction() {
+	ResetRedaction()
+
+	for _, key := range strings.Split(*logRedactionKeys, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		AddRedactionKey(key)
+	}
+
+	for _, name := range strings.Split(*logRedactionPatterns, ",") {
+		name = strings.TrimSpace(name)
+
+		for _, pattern := range builtinRedactionPatterns {
+			if pattern.Name == name {
+				AddRedactionPattern(pattern)
+			}
+		}
+	}
+}
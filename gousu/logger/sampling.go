@@ -0,0 +1,276 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chakrit/go-bunyan"
+	"github.com/namsral/flag"
+)
+
+var (
+	logSamplingFirst      = flag.Int("log_sampling_first", 0, "")
+	logSamplingThereafter = flag.Int("log_sampling_thereafter", 0, "")
+	logSamplingInterval   = flag.Int("log_sampling_interval", 1, "")
+)
+
+// maxSamplingCounters limits the number of message templates tracked per sampler
+const maxSamplingCounters = 10000
+
+// SamplingConfig configures the rate limiting of log messages
+//
+// Within each interval the first First messages of a message template are logged,
+// afterwards only every Thereafter-th message is logged (or none if Thereafter is 0).
+// The number of suppressed messages is logged periodically after the interval ended,
+// pending summaries are logged on Close.
+type SamplingConfig struct {
+	First      int
+	Thereafter int
+	Interval   time.Duration
+}
+
+type samplingCounter struct {
+	intervalStart time.Time
+	count         int
+	suppressed    int
+	// log is the logger of the last suppressed message, used for the summary
+	log bunyan.Log
+}
+
+type samplingSummary struct {
+	log        bunyan.Log
+	msg        string
+	suppressed int
+}
+
+type sampler struct {
+	config   *SamplingConfig
+	mutex    sync.Mutex
+	counters map[string]*samplingCounter
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// check returns if a message should be logged and the number of messages
+// suppressed during the last interval (if a new interval started before the
+// summary was flushed)
+func (s *sampler) check(log bunyan.Log, level bunyan.Level, msg string, now time.Time) (bool, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := level.String() + ":" + msg
+
+	counter, ok := s.counters[key]
+	if !ok {
+		if len(s.counters) >= maxSamplingCounters {
+			s.prune(now)
+		}
+
+		counter = &samplingCounter{
+			intervalStart: now,
+		}
+		s.counters[key] = counter
+	}
+
+	suppressedBefore := 0
+
+	if now.Sub(counter.intervalStart) >= s.config.Interval {
+		suppressedBefore = counter.suppressed
+
+		counter.intervalStart = now
+		counter.count = 0
+		counter.suppressed = 0
+	}
+
+	counter.count++
+
+	if counter.count <= s.config.First {
+		return true, suppressedBefore
+	}
+
+	if s.config.Thereafter > 0 && (counter.count-s.config.First)%s.config.Thereafter == 0 {
+		return true, suppressedBefore
+	}
+
+	counter.suppressed++
+	counter.log = log
+
+	return false, suppressedBefore
+}
+
+// prune removes all counters of expired intervals without suppressed messages
+func (s *sampler) prune(now time.Time) {
+	for key, counter := range s.counters {
+		if counter.suppressed == 0 && now.Sub(counter.intervalStart) >= s.config.Interval {
+			delete(s.counters, key)
+		}
+	}
+}
+
+// flush logs the summaries of all counters with an expired interval (or of all
+// counters if all is true) and removes them
+func (s *sampler) flush(now time.Time, all bool) {
+	summaries := []*samplingSummary{}
+
+	s.mutex.Lock()
+
+	for key, counter := range s.counters {
+		if !all && now.Sub(counter.intervalStart) < s.config.Interval {
+			continue
+		}
+
+		if counter.suppressed > 0 {
+			_, msg, _ := strings.Cut(key, ":")
+
+			summaries = append(summaries, &samplingSummary{
+				log:        counter.log,
+				msg:        msg,
+				suppressed: counter.suppressed,
+			})
+		}
+
+		delete(s.counters, key)
+	}
+
+	s.mutex.Unlock()
+
+	for _, summary := range summaries {
+		logSamplingSummary(summary.log, summary.msg, summary.suppressed, s.config.Interval)
+	}
+}
+
+// run flushes the summaries once per interval until the sampler is closed
+func (s *sampler) run() {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.flush(now, false)
+		}
+	}
+}
+
+// close stops the periodic flushing and logs all pending summaries
+func (s *sampler) close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+
+		s.flush(time.Now(), true)
+	})
+}
+
+func logSamplingSummary(log bunyan.Log, msg string, suppressed int, interval time.Duration) {
+	log.Warnf("Suppressed %d log messages '%s' during the last %s", suppressed, msg, interval)
+}
+
+func newSampler(config *SamplingConfig) *sampler {
+	configCopy := *config
+	if configCopy.Interval <= 0 {
+		configCopy.Interval = time.Second
+	}
+
+	s := &sampler{
+		config:   &configCopy,
+		counters: map[string]*samplingCounter{},
+		stop:     make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+var (
+	globalSampler     *sampler
+	componentSamplers = map[string]*sampler{}
+	mutexSamplers     sync.RWMutex
+)
+
+// SetSampling configures the sampling of log messages for all components
+//
+// Passing nil disables the sampling. The sampling can also be configured via the
+// config properties "log_sampling_first", "log_sampling_thereafter" and
+// "log_sampling_interval" [s]
+func SetSampling(config *SamplingConfig) {
+	mutexSamplers.Lock()
+	defer mutexSamplers.Unlock()
+
+	if globalSampler != nil {
+		globalSampler.close()
+	}
+
+	if config == nil {
+		globalSampler = nil
+
+		return
+	}
+
+	globalSampler = newSampler(config)
+}
+
+// SetComponentSampling configures the sampling of log messages for a specific
+// component, overriding the global sampling
+//
+// Passing nil removes the component's sampling so the global sampling is used again
+func SetComponentSampling(componentName string, config *SamplingConfig) {
+	mutexSamplers.Lock()
+	defer mutexSamplers.Unlock()
+
+	componentSampler, ok := componentSamplers[componentName]
+	if ok {
+		componentSampler.close()
+	}
+
+	if config == nil {
+		delete(componentSamplers, componentName)
+
+		return
+	}
+
+	componentSamplers[componentName] = newSampler(config)
+}
+
+func getSampler(componentName string) *sampler {
+	mutexSamplers.RLock()
+	defer mutexSamplers.RUnlock()
+
+	sampler, ok := componentSamplers[componentName]
+	if ok {
+		return sampler
+	}
+
+	return globalSampler
+}
+
+func initSampling() {
+	if *logSamplingFirst <= 0 {
+		SetSampling(nil)
+
+		return
+	}
+
+	SetSampling(&SamplingConfig{
+		First:      *logSamplingFirst,
+		Thereafter: *logSamplingThereafter,
+		Interval:   time.Duration(*logSamplingInterval) * time.Second,
+	})
+}
+
+// closeSampling stops all samplers and logs their pending summaries
+func closeSampling() {
+	mutexSamplers.Lock()
+	defer mutexSamplers.Unlock()
+
+	if globalSampler != nil {
+		globalSampler.close()
+	}
+
+	for _, componentSampler := range componentSamplers {
+		componentSampler.close()
+	}
+}
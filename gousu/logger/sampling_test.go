@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chakrit/go-bunyan"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplerCheck(t *testing.T) {
+	s := newSampler(&SamplingConfig{
+		First:      2,
+		Thereafter: 3,
+		Interval:   time.Second,
+	})
+	defer s.close()
+
+	now := time.Now()
+	log := GetLogger("test").Log
+
+	results := []bool{}
+	for i := 0; i < 8; i++ {
+		allowed, suppressed := s.check(log, bunyan.ERROR, "Consumer error: %s", now)
+		assert.Equal(t, 0, suppressed)
+
+		results = append(results, allowed)
+	}
+
+	assert.Equal(t, []bool{true, true, false, false, true, false, false, true}, results)
+
+	// Other templates are counted separately
+	allowed, _ := s.check(log, bunyan.ERROR, "Other error: %s", now)
+	assert.True(t, allowed)
+
+	allowed, suppressed := s.check(log, bunyan.ERROR, "Consumer error: %s", now.Add(time.Second))
+	assert.True(t, allowed)
+	assert.Equal(t, 4, suppressed)
+}
+
+func TestSamplerFlush(t *testing.T) {
+	messages := make(chan string, 10)
+
+	log := bunyan.NewStdLogger("test", bunyan.SinkFunc(func(record bunyan.Record) error {
+		messages <- record["msg"].(string)
+
+		return nil
+	}))
+
+	s := newSampler(&SamplingConfig{
+		First:    1,
+		Interval: 20 * time.Millisecond,
+	})
+	defer s.close()
+
+	for i := 0; i < 3; i++ {
+		s.check(log, bunyan.ERROR, "Consumer error: %s", time.Now())
+	}
+
+	// The summary is logged without further messages of the template
+	select {
+	case msg := <-messages:
+		assert.Equal(t, "Suppressed 2 log messages 'Consumer error: %s' during the last 20ms", msg)
+	case <-time.After(time.Second):
+		t.Fatal("summary was not flushed")
+	}
+
+	s.check(log, bunyan.ERROR, "Consumer error: %s", time.Now())
+	s.check(log, bunyan.ERROR, "Consumer error: %s", time.Now())
+	s.close()
+
+	assert.Equal(t, "Suppressed 1 log messages 'Consumer error: %s' during the last 20ms", <-messages)
+}
+
+func TestGetSampler(t *testing.T) {
+	SetSampling(&SamplingConfig{First: 1, Interval: time.Second})
+	SetComponentSampling("service.kafka", &SamplingConfig{First: 5, Interval: time.Second})
+
+	assert.Equal(t, 1, getSampler("controller.api").config.First)
+	assert.Equal(t, 5, getSampler("service.kafka").config.First)
+
+	SetComponentSampling("service.kafka", nil)
+	SetSampling(nil)
+
+	assert.Nil(t, getSampler("service.kafka"))
+}
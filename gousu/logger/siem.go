@@ -0,0 +1,201 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/indece-official/go-gousu/v2/gousu/siem"
+	"github.com/namsral/flag"
+)
+
+var (
+	siemSinkFormat          = flag.String("siem_sink_format", "cef", "")
+	siemSinkFile            = flag.String("siem_sink_file", "", "")
+	siemSinkSyslogAddress   = flag.String("siem_sink_syslog_address", "", "")
+	siemSinkSyslogNetwork   = flag.String("siem_sink_syslog_network", "udp", "")
+	siemSinkBufferSize      = flag.Int("siem_sink_buffer_size", 1000, "")
+	siemSinkBackPressure    = flag.String("siem_sink_backpressure", string(siem.DefaultBackPressurePolicy), "")
+	siemSinkFormatterVendor = flag.String("siem_sink_vendor", "gousu", "")
+	siemAuditFile           = flag.String("siem_audit_file", "", "")
+	siemAuditKey            = flag.String("siem_audit_key", "", "")
+)
+
+var (
+	siemSinks       = []siem.ISink{}
+	mutexSiemSinks  sync.RWMutex
+	siemProductName string
+	siemHostname    string
+	siemDetector    *siem.Detector
+)
+
+// SetSiemDetector sets a detector observing all siem events logged via Log.SiemEvent
+//
+// Escalated events (e.g. detected brute-force attacks) are logged as separate siem
+// events. Passing nil disables the detection.
+func SetSiemDetector(detector *siem.Detector) {
+	mutexSiemSinks.Lock()
+	defer mutexSiemSinks.Unlock()
+
+	siemDetector = detector
+}
+
+func (l *Log) detectSiemAttacks(event *siem.Event) {
+	mutexSiemSinks.RLock()
+	detector := siemDetector
+	mutexSiemSinks.RUnlock()
+
+	if detector == nil {
+		return
+	}
+
+	escalatedEvents, err := detector.Observe(event)
+	if err != nil {
+		l.Errorf("Can't detect attacks from siem event: %s", err)
+
+		return
+	}
+
+	for _, escalatedEvent := range escalatedEvents {
+		l.SiemEvent(escalatedEvent, "Attack detected: %s", escalatedEvent.Reason.String)
+	}
+}
+
+// AddSiemSink registers a sink receiving all siem events logged via Log.SiemEvent
+//
+// Sinks receive events independent of the log level
+func AddSiemSink(sink siem.ISink) {
+	mutexSiemSinks.Lock()
+	defer mutexSiemSinks.Unlock()
+
+	siemSinks = append(siemSinks, sink)
+}
+
+// CloseSiemSinks flushes and closes all registered siem sinks
+func CloseSiemSinks() error {
+	mutexSiemSinks.Lock()
+	defer mutexSiemSinks.Unlock()
+
+	var lastErr error
+
+	for _, sink := range siemSinks {
+		err := sink.Close()
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	siemSinks = []siem.ISink{}
+
+	return lastErr
+}
+
+func (l *Log) writeSiemSinks(event *siem.Event, msg string, args ...interface{}) {
+	// Sinks are written without holding the lock, so a blocking sink doesn't
+	// prevent the sinks from being replaced
+	mutexSiemSinks.RLock()
+	sinks := siemSinks
+	mutexSiemSinks.RUnlock()
+
+	if len(sinks) == 0 {
+		return
+	}
+
+	record := &siem.Record{
+		Time:      time.Now(),
+		Event:     event,
+		Level:     event.Level(),
+		Message:   Redact(fmt.Sprintf(msg, args...)),
+		Product:   siemProductName,
+		Component: l.component,
+		Hostname:  siemHostname,
+	}
+
+	for _, sink := range sinks {
+		err := sink.Write(record)
+		if err != nil {
+			l.Errorf("Can't write siem event to sink: %s", err)
+		}
+	}
+}
+
+func newSiemFormatter() (siem.IFormatter, error) {
+	switch *siemSinkFormat {
+	case "cef":
+		return siem.NewCEFFormatter(*siemSinkFormatterVendor, ""), nil
+	case "leef":
+		return siem.NewLEEFFormatter(*siemSinkFormatterVendor, ""), nil
+	case "json":
+		return siem.NewJSONFormatter(), nil
+	default:
+		return nil, fmt.Errorf("unknown siem sink format '%s'", *siemSinkFormat)
+	}
+}
+
+func (l *Log) newAsyncSiemSink(sink siem.ISink) (siem.ISink, error) {
+	return siem.NewAsyncSink(
+		sink,
+		*siemSinkBufferSize,
+		siem.BackPressurePolicy(*siemSinkBackPressure),
+		func(err error) {
+			l.Errorf("Can't write siem event to sink: %s", err)
+		},
+	)
+}
+
+func addAsyncSiemSink(log *Log, sink siem.ISink) {
+	asyncSink, err := log.newAsyncSiemSink(sink)
+	if err != nil {
+		log.Errorf("Can't initialize siem sink: %s", err)
+
+		sink.Close()
+
+		return
+	}
+
+	AddSiemSink(asyncSink)
+}
+
+// initSiemSinks replaces all siem sinks with the ones configured via the
+// config properties "siem_audit_file", "siem_audit_key", "siem_sink_file" and
+// "siem_sink_syslog_address"
+func initSiemSinks(log *Log, projectName string) {
+	CloseSiemSinks()
+
+	siemProductName = projectName
+	siemHostname, _ = os.Hostname()
+
+	if *siemAuditFile != "" {
+		store, err := siem.NewFileAuditStore(*siemAuditFile, []byte(*siemAuditKey))
+		if err != nil {
+			log.Errorf("Can't initialize siem audit file: %s", err)
+		} else {
+			AddSiemSink(siem.NewAuditSink(store))
+		}
+	}
+
+	if *siemSinkFile == "" && *siemSinkSyslogAddress == "" {
+		return
+	}
+
+	formatter, err := newSiemFormatter()
+	if err != nil {
+		log.Errorf("Can't initialize siem sinks: %s", err)
+
+		return
+	}
+
+	if *siemSinkFile != "" {
+		sink, err := siem.NewFileSink(*siemSinkFile, formatter)
+		if err != nil {
+			log.Errorf("Can't initialize siem file sink: %s", err)
+		} else {
+			addAsyncSiemSink(log, sink)
+		}
+	}
+
+	if *siemSinkSyslogAddress != "" {
+		addAsyncSiemSink(log, siem.NewSyslogSink(*siemSinkSyslogNetwork, *siemSinkSyslogAddress, formatter))
+	}
+}
@@ -0,0 +1,320 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Labels are the label names and values of a metric series
+type Labels map[string]string
+
+// DefaultBuckets are the default histogram buckets for latencies in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	value float64
+	mutex sync.Mutex
+}
+
+// Inc increases the counter by 1
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increases the counter by delta (negative values are ignored)
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.value += delta
+}
+
+// Value returns the current value of the counter
+func (c *Counter) Value() float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.value
+}
+
+// Histogram counts observed values in buckets
+type Histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+	mutex   sync.Mutex
+}
+
+// Observe adds a value to the histogram
+func (h *Histogram) Observe(value float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for i, bucket := range h.buckets {
+		if value <= bucket {
+			h.counts[i]++
+		}
+	}
+
+	h.sum += value
+	h.count++
+}
+
+// Count returns the number of observed values
+func (h *Histogram) Count() uint64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.count
+}
+
+// Sum returns the sum of all observed values
+func (h *Histogram) Sum() float64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.sum
+}
+
+const (
+	typeCounter   = "counter"
+	typeHistogram = "histogram"
+)
+
+type series struct {
+	labels    Labels
+	counter   *Counter
+	histogram *Histogram
+}
+
+type family struct {
+	name       string
+	help       string
+	metricType string
+	buckets    []float64
+	series     map[string]*series
+}
+
+// Registry holds metrics and exports them in the prometheus text format
+type Registry struct {
+	families map[string]*family
+	mutex    sync.Mutex
+}
+
+func labelsKey(labels Labels) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"\xff"+labels[key])
+	}
+
+	return strings.Join(parts, "\xfe")
+}
+
+func (r *Registry) getSeries(name string, help string, metricType string, buckets []float64, labels Labels) (*series, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{
+			name:       name,
+			help:       help,
+			metricType: metricType,
+			buckets:    buckets,
+			series:     map[string]*series{},
+		}
+
+		r.families[name] = f
+	}
+
+	if f.metricType != metricType {
+		return nil, fmt.Errorf("metric %s is already registered as %s", name, f.metricType)
+	}
+
+	key := labelsKey(labels)
+
+	s, ok := f.series[key]
+	if !ok {
+		copiedLabels := Labels{}
+		for labelName, labelValue := range labels {
+			copiedLabels[labelName] = labelValue
+		}
+
+		s = &series{
+			labels: copiedLabels,
+		}
+
+		switch metricType {
+		case typeCounter:
+			s.counter = &Counter{}
+		case typeHistogram:
+			s.histogram = &Histogram{
+				buckets: f.buckets,
+				counts:  make([]uint64, len(f.buckets)),
+			}
+		}
+
+		f.series[key] = s
+	}
+
+	return s, nil
+}
+
+// Counter returns the counter with the name and labels, it is created if it doesn't exist
+//
+// Panics if the name is already registered as another metric type
+func (r *Registry) Counter(name string, help string, labels Labels) *Counter {
+	s, err := r.getSeries(name, help, typeCounter, nil, labels)
+	if err != nil {
+		panic(err)
+	}
+
+	return s.counter
+}
+
+// Histogram returns the histogram with the name and labels, it is created if it doesn't exist
+//
+// The buckets (defaults to DefaultBuckets) of the first call for a name are used for all
+// series with this name. Panics if the name is already registered as another metric type
+func (r *Registry) Histogram(name string, help string, buckets []float64, labels Labels) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sortedBuckets := append([]float64{}, buckets...)
+	sort.Float64s(sortedBuckets)
+
+	s, err := r.getSeries(name, help, typeHistogram, sortedBuckets, labels)
+	if err != nil {
+		panic(err)
+	}
+
+	return s.histogram
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
+
+func formatLabels(labels Labels, extraName string, extraValue string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, key, escapeLabelValue(labels[key])))
+	}
+
+	if extraName != "" {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, extraName, escapeLabelValue(extraValue)))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// WritePrometheus writes all metrics in the prometheus text exposition format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mutex.Lock()
+
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+
+	seriesByFamily := map[string][]*series{}
+	for _, f := range families {
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			seriesByFamily[f.name] = append(seriesByFamily[f.name], f.series[key])
+		}
+	}
+
+	r.mutex.Unlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	builder := &strings.Builder{}
+
+	for _, f := range families {
+		if f.help != "" {
+			fmt.Fprintf(builder, "# HELP %s %s\n", f.name, strings.ReplaceAll(f.help, "\n", " "))
+		}
+
+		fmt.Fprintf(builder, "# TYPE %s %s\n", f.name, f.metricType)
+
+		for _, s := range seriesByFamily[f.name] {
+			switch f.metricType {
+			case typeCounter:
+				fmt.Fprintf(builder, "%s%s %s\n", f.name, formatLabels(s.labels, "", ""), formatValue(s.counter.Value()))
+			case typeHistogram:
+				s.histogram.mutex.Lock()
+
+				for i, bucket := range s.histogram.buckets {
+					fmt.Fprintf(builder, "%s_bucket%s %d\n", f.name, formatLabels(s.labels, "le", formatValue(bucket)), s.histogram.counts[i])
+				}
+
+				fmt.Fprintf(builder, "%s_bucket%s %d\n", f.name, formatLabels(s.labels, "le", "+Inf"), s.histogram.count)
+				fmt.Fprintf(builder, "%s_sum%s %s\n", f.name, formatLabels(s.labels, "", ""), formatValue(s.histogram.sum))
+				fmt.Fprintf(builder, "%s_count%s %d\n", f.name, formatLabels(s.labels, "", ""), s.histogram.count)
+
+				s.histogram.mutex.Unlock()
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, builder.String())
+
+	return err
+}
+
+// NewRegistry creates a new initialized instance of Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		families: map[string]*family{},
+	}
+}
+
+// Default is the registry exposed by the actuator on /metrics
+var Default = NewRegistry()
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+
+	registry.Counter("http_requests_total", "Number of requests", Labels{"route": "/users/{id}", "status": "200"}).Inc()
+	registry.Counter("http_requests_total", "Number of requests", Labels{"status": "200", "route": "/users/{id}"}).Add(2)
+
+	histogram := registry.Histogram("http_request_duration_seconds", "Request duration", []float64{1, 0.1}, Labels{"route": "/"})
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+	histogram.Observe(2)
+
+	builder := &strings.Builder{}
+	require.NoError(t, registry.WritePrometheus(builder))
+
+	assert.Equal(t, `# HELP http_request_duration_seconds Request duration
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{route="/",le="0.1"} 1
+http_request_duration_seconds_bucket{route="/",le="1"} 2
+http_request_duration_seconds_bucket{route="/",le="+Inf"} 3
+http_request_duration_seconds_sum{route="/"} 2.55
+http_request_duration_seconds_count{route="/"} 3
+# HELP http_requests_total Number of requests
+# TYPE http_requests_total counter
+http_requests_total{route="/users/{id}",status="200"} 3
+`, builder.String())
+}
+
+func TestRegistryTypeConflict(t *testing.T) {
+	registry := NewRegistry()
+
+	registry.Counter("requests", "", nil)
+
+	assert.Panics(t, func() {
+		registry.Histogram("requests", "", nil, nil)
+	})
+}
@@ -126,6 +126,11 @@ func (r *Runner) Run() {
 
 		r.log.Infof("Service '%s' stopped", name)
 	}
+
+	err := logger.Close()
+	if err != nil {
+		r.log.Errorf("Error closing logger: %s", err)
+	}
 }
 
 // AwaitReady is a blocking function waiting for the Runner to have started all
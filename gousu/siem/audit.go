@@ -0,0 +1,340 @@
+package siem
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditRecord is a siem event stored in a hash-chained audit trail
+//
+// Each record contains the hash of its predecessor, so modified, inserted or
+// removed records can be detected via VerifyAudit
+//
+// Without a key the chain is a plain sha256 hash chain, which only detects
+// accidental corruption: anyone able to write to the store can rewrite records
+// and recompute the chain. With a key the chain is a HMAC-SHA256 chain, which
+// can only be recomputed by someone knowing the key, so the key must not be
+// stored next to the audit trail.
+type AuditRecord struct {
+	Sequence       int64      `json:"seq"`
+	Time           time.Time  `json:"time"`
+	Type           EventType  `json:"type"`
+	Level          EventLevel `json:"level"`
+	Message        string     `json:"msg"`
+	Product        string     `json:"product,omitempty"`
+	Component      string     `json:"component,omitempty"`
+	Hostname       string     `json:"hostname,omitempty"`
+	UserIdentifier string     `json:"user_identifier,omitempty"`
+	SourceIP       string     `json:"source_ip,omitempty"`
+	SourceRealIP   string     `json:"source_real_ip,omitempty"`
+	TargetResource string     `json:"target_resource,omitempty"`
+	Outcome        string     `json:"outcome,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+	SessionID      string     `json:"session_id,omitempty"`
+	UserAgent      string     `json:"user_agent,omitempty"`
+	PrevHash       string     `json:"prev_hash"`
+	Hash           string     `json:"hash"`
+}
+
+// ComputeHash calculates the HMAC-SHA256 (or the sha256 hash if key is empty)
+// of the record's content including the hash of its predecessor
+func (r *AuditRecord) ComputeHash(key []byte) (string, error) {
+	content := *r
+	content.Time = content.Time.UTC()
+	content.Hash = ""
+
+	data, err := json.Marshal(&content)
+	if err != nil {
+		return "", err
+	}
+
+	if len(key) == 0 {
+		hash := sha256.Sum256(data)
+
+		return hex.EncodeToString(hash[:]), nil
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Link chains the record to its predecessor (nil for the first record) and
+// sets its sequence and hash (see ComputeHash)
+func (r *AuditRecord) Link(prev *AuditRecord, key []byte) error {
+	r.Sequence = 1
+	r.PrevHash = ""
+
+	if prev != nil {
+		r.Sequence = prev.Sequence + 1
+		r.PrevHash = prev.Hash
+	}
+
+	hash, err := r.ComputeHash(key)
+	if err != nil {
+		return fmt.Errorf("can't compute hash of audit record: %s", err)
+	}
+
+	r.Hash = hash
+
+	return nil
+}
+
+// NewAuditRecord creates a new unlinked audit record from a siem record
+func NewAuditRecord(record *Record) *AuditRecord {
+	return &AuditRecord{
+		// Truncated to microseconds as supported by most databases
+		Time:           record.Time.UTC().Truncate(time.Microsecond),
+		Type:           record.Event.Type,
+		Level:          record.Level,
+		Message:        record.Message,
+		Product:        record.Product,
+		Component:      record.Component,
+		Hostname:       record.Hostname,
+		UserIdentifier: record.Event.UserIdentifier.String,
+		SourceIP:       record.Event.SourceIP.String,
+		SourceRealIP:   record.Event.SourceRealIP.String,
+		TargetResource: record.Event.TargetResource.String,
+		Outcome:        record.Event.Outcome.String,
+		Reason:         record.Event.Reason.String,
+		SessionID:      record.Event.SessionID.String,
+		UserAgent:      record.Event.UserAgent.String,
+	}
+}
+
+// IAuditStore defines the interface of an append-only storage for audit records
+type IAuditStore interface {
+	// Append links the record to the last stored record (via AuditRecord.Link
+	// with the store's key) and stores it atomically
+	Append(record *AuditRecord) error
+	// Iterate calls fn for all stored records ordered by their sequence
+	Iterate(fn func(record *AuditRecord) error) error
+	Close() error
+}
+
+// AuditSink is an ISink writing all siem events to an IAuditStore
+type AuditSink struct {
+	store IAuditStore
+}
+
+var _ ISink = (*AuditSink)(nil)
+
+// Write appends a siem event to the audit trail
+func (s *AuditSink) Write(record *Record) error {
+	return s.store.Append(NewAuditRecord(record))
+}
+
+// Close closes the audit store
+func (s *AuditSink) Close() error {
+	return s.store.Close()
+}
+
+// NewAuditSink creates a new initialized instance of AuditSink
+func NewAuditSink(store IAuditStore) *AuditSink {
+	return &AuditSink{
+		store: store,
+	}
+}
+
+// AuditVerificationError is returned by VerifyAudit if the audit trail was tampered with
+type AuditVerificationError struct {
+	Sequence int64
+	Reason   string
+}
+
+func (e *AuditVerificationError) Error() string {
+	return fmt.Sprintf("audit trail invalid at record %d: %s", e.Sequence, e.Reason)
+}
+
+// VerifyAudit checks the hash chain of all records in an audit store and returns
+// the number of valid records
+//
+// The key must match the key the records were stored with (see AuditRecord).
+// If a record was modified, inserted or removed an *AuditVerificationError is returned.
+// Removing records from the end of the trail can only be detected by comparing the
+// returned count with a previously known count.
+func VerifyAudit(store IAuditStore, key []byte) (int64, error) {
+	var prev *AuditRecord
+
+	count := int64(0)
+
+	err := store.Iterate(func(record *AuditRecord) error {
+		expectedSequence := int64(1)
+		expectedPrevHash := ""
+
+		if prev != nil {
+			expectedSequence = prev.Sequence + 1
+			expectedPrevHash = prev.Hash
+		}
+
+		if record.Sequence != expectedSequence {
+			return &AuditVerificationError{
+				Sequence: record.Sequence,
+				Reason:   fmt.Sprintf("expected sequence %d (gap or reordering)", expectedSequence),
+			}
+		}
+
+		if record.PrevHash != expectedPrevHash {
+			return &AuditVerificationError{
+				Sequence: record.Sequence,
+				Reason:   "hash of previous record does not match",
+			}
+		}
+
+		hash, err := record.ComputeHash(key)
+		if err != nil {
+			return fmt.Errorf("can't compute hash of audit record %d: %s", record.Sequence, err)
+		}
+
+		if !hmac.Equal([]byte(hash), []byte(record.Hash)) {
+			return &AuditVerificationError{
+				Sequence: record.Sequence,
+				Reason:   "record was modified",
+			}
+		}
+
+		prev = record
+		count++
+
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// FileAuditStore stores audit records as json lines in an append-only file
+//
+// The file must only be written by a single process
+type FileAuditStore struct {
+	path  string
+	key   []byte
+	file  *os.File
+	last  *AuditRecord
+	mutex sync.Mutex
+}
+
+var _ IAuditStore = (*FileAuditStore)(nil)
+
+// Append links the record to the last record in the file and appends it
+func (s *FileAuditStore) Append(record *AuditRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := record.Link(s.last, s.key)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("can't encode audit record: %s", err)
+	}
+
+	_, err = s.file.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("can't write audit record to %s: %s", s.path, err)
+	}
+
+	err = s.file.Sync()
+	if err != nil {
+		return fmt.Errorf("can't sync audit file %s: %s", s.path, err)
+	}
+
+	s.last = record
+
+	return nil
+}
+
+// Iterate calls fn for all records in the file
+func (s *FileAuditStore) Iterate(fn func(record *AuditRecord) error) error {
+	return iterateAuditFile(s.path, fn)
+}
+
+// Close closes the audit file
+func (s *FileAuditStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.file.Close()
+}
+
+func iterateAuditFile(path string, fn func(record *AuditRecord) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("can't open audit file %s: %s", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		record := &AuditRecord{}
+
+		err = json.Unmarshal(scanner.Bytes(), record)
+		if err != nil {
+			return fmt.Errorf("can't decode audit record in line %d of %s: %s", line, path, err)
+		}
+
+		err = fn(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// NewFileAuditStore opens (or creates) an audit file and loads its last record
+//
+// Records are chained with the given key (see AuditRecord), an empty key
+// uses a plain sha256 hash chain
+func NewFileAuditStore(path string, key []byte) (*FileAuditStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("can't open audit file %s: %s", path, err)
+	}
+
+	s := &FileAuditStore{
+		path: path,
+		key:  key,
+		file: file,
+	}
+
+	err = iterateAuditFile(path, func(record *AuditRecord) error {
+		s.last = record
+
+		return nil
+	})
+	if err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// VerifyAuditFile checks the hash chain of an audit file written by FileAuditStore
+func VerifyAuditFile(path string, key []byte) (int64, error) {
+	return VerifyAudit(&FileAuditStore{path: path}, key)
+}
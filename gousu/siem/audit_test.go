@@ -0,0 +1,90 @@
+package siem
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testAuditKey = []byte("test-audit-key")
+
+func TestFileAuditStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	store, err := NewFileAuditStore(path, testAuditKey)
+	assert.NoError(t, err)
+
+	sink := NewAuditSink(store)
+	assert.NoError(t, sink.Write(newTestRecord()))
+	assert.NoError(t, sink.Write(newTestRecord()))
+	assert.NoError(t, sink.Close())
+
+	// Reopening continues the hash chain
+	store, err = NewFileAuditStore(path, testAuditKey)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), store.last.Sequence)
+
+	sink = NewAuditSink(store)
+	assert.NoError(t, sink.Write(newTestRecord()))
+	assert.NoError(t, sink.Close())
+
+	count, err := VerifyAuditFile(path, testAuditKey)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// The chain can't be verified without the key
+	count, err = VerifyAuditFile(path, nil)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, &AuditVerificationError{Sequence: 1, Reason: "record was modified"}, err)
+}
+
+func TestAuditRecordComputeHash(t *testing.T) {
+	record := NewAuditRecord(newTestRecord())
+
+	hash, err := record.ComputeHash(nil)
+	assert.NoError(t, err)
+
+	// The keyed hash can't be recomputed without the key
+	keyedHash, err := record.ComputeHash(testAuditKey)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, keyedHash)
+
+	otherKeyHash, err := record.ComputeHash([]byte("other-key"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, keyedHash, otherKeyHash)
+}
+
+func TestVerifyAuditFileTampered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	store, err := NewFileAuditStore(path, nil)
+	assert.NoError(t, err)
+
+	sink := NewAuditSink(store)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, sink.Write(newTestRecord()))
+	}
+	assert.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	// Modified record
+	modified := strings.Replace(lines[1], "userid:5", "userid:6", 1)
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Join([]string{lines[0], modified, lines[2]}, "\n")), 0640))
+
+	count, err := VerifyAuditFile(path, nil)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, &AuditVerificationError{Sequence: 2, Reason: "record was modified"}, err)
+
+	// Removed record
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Join([]string{lines[0], lines[2]}, "\n")), 0640))
+
+	_, err = VerifyAuditFile(path, nil)
+	assert.IsType(t, &AuditVerificationError{}, err)
+	assert.Equal(t, int64(3), err.(*AuditVerificationError).Sequence)
+}
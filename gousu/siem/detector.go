@@ -0,0 +1,228 @@
+package siem
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// IDetectorStore defines the interface of a store counting failures per key
+// within sliding windows
+type IDetectorStore interface {
+	// Add records a failure for the key and returns the number of failures
+	// within the window ending at the given time
+	Add(key string, at time.Time, window time.Duration) (int, error)
+	// Reset removes all failures recorded for the key
+	Reset(key string) error
+}
+
+// MemoryDetectorStore is an in-memory IDetectorStore for single instance deployments
+type MemoryDetectorStore struct {
+	failures map[string][]time.Time
+	mutex    sync.Mutex
+	adds     int
+}
+
+var _ IDetectorStore = (*MemoryDetectorStore)(nil)
+
+// maxMemoryDetectorStoreAdds is the number of adds after which expired keys are removed
+const maxMemoryDetectorStoreAdds = 1000
+
+func pruneFailures(failures []time.Time, at time.Time, window time.Duration) []time.Time {
+	start := at.Add(-window)
+
+	i := 0
+	for i < len(failures) && !failures[i].After(start) {
+		i++
+	}
+
+	return failures[i:]
+}
+
+// Add records a failure for the key and returns the number of failures within the window
+func (s *MemoryDetectorStore) Add(key string, at time.Time, window time.Duration) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.adds++
+	if s.adds >= maxMemoryDetectorStoreAdds {
+		s.adds = 0
+
+		for otherKey, failures := range s.failures {
+			failures = pruneFailures(failures, at, window)
+			if len(failures) == 0 {
+				delete(s.failures, otherKey)
+			} else {
+				s.failures[otherKey] = failures
+			}
+		}
+	}
+
+	failures := append(pruneFailures(s.failures[key], at, window), at)
+	s.failures[key] = failures
+
+	return len(failures), nil
+}
+
+// Reset removes all failures recorded for the key
+func (s *MemoryDetectorStore) Reset(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.failures, key)
+
+	return nil
+}
+
+// NewMemoryDetectorStore creates a new initialized instance of MemoryDetectorStore
+func NewMemoryDetectorStore() *MemoryDetectorStore {
+	return &MemoryDetectorStore{
+		failures: map[string][]time.Time{},
+	}
+}
+
+// DetectorOptions configures a Detector
+type DetectorOptions struct {
+	// Store used for counting failures, defaults to a MemoryDetectorStore
+	Store IDetectorStore
+	// Window is the duration of the sliding window (defaults to 5 minutes)
+	Window time.Duration
+	// MaxFailuresPerUser is the number of failures per user identifier within the
+	// window considered as an attack (0 disables the detection per user)
+	MaxFailuresPerUser int
+	// MaxFailuresPerSourceIP is the number of failures per source ip within the
+	// window considered as an attack (0 disables the detection per source ip)
+	MaxFailuresPerSourceIP int
+	// LockoutHandler is called once when a user identifier exceeds MaxFailuresPerUser
+	LockoutHandler func(userIdentifier string, event *Event)
+}
+
+// Detector detects brute-force attacks from failed login and authentication
+// events and escalates them to the matching "attact" event types
+//
+// An attack is escalated once when a threshold is reached, further failures
+// within the window are not escalated again
+type Detector struct {
+	options *DetectorOptions
+}
+
+// detectorEscalations maps the failure event types to their attack event types
+var detectorEscalations = map[EventType]EventType{
+	EventTypeLoginFailed:          EventTypeLoginFailedAttact,
+	EventTypeAuthenticationFailed: EventTypeAuthenticationFailedAttact,
+}
+
+// detectorResets contains the event types resetting the failures of a user
+var detectorResets = map[EventType]bool{
+	EventTypeLoginSuccess:          true,
+	EventTypeAuthenticationSuccess: true,
+	EventTypeUserUnlocked:          true,
+}
+
+func (d *Detector) userKey(eventType EventType, userIdentifier string) string {
+	return fmt.Sprintf("%s:user:%s", eventType, userIdentifier)
+}
+
+func (d *Detector) escalate(event *Event, attackType EventType, reason string) *Event {
+	escalated := *event
+
+	escalated.Type = attackType
+	escalated.Outcome = null.StringFrom(EventOutcomeFailure)
+	escalated.Reason = null.StringFrom(reason)
+
+	return &escalated
+}
+
+// Observe processes a siem event and returns the escalated events if an attack was detected
+func (d *Detector) Observe(event *Event) ([]*Event, error) {
+	if detectorResets[event.Type] && event.UserIdentifier.Valid {
+		for failureType := range detectorEscalations {
+			err := d.options.Store.Reset(d.userKey(failureType, event.UserIdentifier.String))
+			if err != nil {
+				return nil, fmt.Errorf("can't reset failures for user: %s", err)
+			}
+		}
+
+		return nil, nil
+	}
+
+	attackType, ok := detectorEscalations[event.Type]
+	if !ok {
+		return nil, nil
+	}
+
+	now := time.Now()
+	escalated := []*Event{}
+
+	if d.options.MaxFailuresPerUser > 0 && event.UserIdentifier.Valid {
+		count, err := d.options.Store.Add(
+			d.userKey(event.Type, event.UserIdentifier.String),
+			now,
+			d.options.Window,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("can't count failures for user: %s", err)
+		}
+
+		if count == d.options.MaxFailuresPerUser {
+			escalatedEvent := d.escalate(
+				event,
+				attackType,
+				fmt.Sprintf("%d failures for user %s within %s", count, event.UserIdentifier.String, d.options.Window),
+			)
+
+			escalated = append(escalated, escalatedEvent)
+
+			if d.options.LockoutHandler != nil {
+				d.options.LockoutHandler(event.UserIdentifier.String, escalatedEvent)
+			}
+		}
+	}
+
+	sourceIP := event.SourceRealIP
+	if !sourceIP.Valid || sourceIP.String == "" {
+		sourceIP = event.SourceIP
+	}
+
+	if d.options.MaxFailuresPerSourceIP > 0 && sourceIP.Valid && sourceIP.String != "" {
+		count, err := d.options.Store.Add(
+			fmt.Sprintf("%s:ip:%s", event.Type, sourceIP.String),
+			now,
+			d.options.Window,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("can't count failures for source ip: %s", err)
+		}
+
+		if count == d.options.MaxFailuresPerSourceIP {
+			escalated = append(escalated, d.escalate(
+				event,
+				attackType,
+				fmt.Sprintf("%d failures from source ip %s within %s", count, sourceIP.String, d.options.Window),
+			))
+		}
+	}
+
+	return escalated, nil
+}
+
+// NewDetector creates a new initialized instance of Detector
+func NewDetector(options *DetectorOptions) *Detector {
+	if options == nil {
+		options = &DetectorOptions{}
+	}
+
+	if options.Store == nil {
+		options.Store = NewMemoryDetectorStore()
+	}
+
+	if options.Window <= 0 {
+		options.Window = 5 * time.Minute
+	}
+
+	return &Detector{
+		options: options,
+	}
+}
@@ -0,0 +1,92 @@
+package siem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestMemoryDetectorStore(t *testing.T) {
+	store := NewMemoryDetectorStore()
+	now := time.Now()
+
+	count, err := store.Add("test", now, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = store.Add("test", now.Add(30*time.Second), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = store.Add("test", now.Add(80*time.Second), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, store.Reset("test"))
+
+	count, err = store.Add("test", now.Add(90*time.Second), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestDetector(t *testing.T) {
+	lockedUsers := []string{}
+
+	detector := NewDetector(&DetectorOptions{
+		MaxFailuresPerUser:     3,
+		MaxFailuresPerSourceIP: 5,
+		LockoutHandler: func(userIdentifier string, event *Event) {
+			lockedUsers = append(lockedUsers, userIdentifier)
+		},
+	})
+
+	failure := &Event{
+		Type:           EventTypeLoginFailed,
+		UserIdentifier: null.StringFrom("user1"),
+		SourceIP:       null.StringFrom("10.0.0.1"),
+	}
+
+	for i := 0; i < 2; i++ {
+		escalated, err := detector.Observe(failure)
+		assert.NoError(t, err)
+		assert.Empty(t, escalated)
+	}
+
+	escalated, err := detector.Observe(failure)
+	assert.NoError(t, err)
+	assert.Len(t, escalated, 1)
+	assert.Equal(t, EventTypeLoginFailedAttact, escalated[0].Type)
+	assert.Equal(t, "user1", escalated[0].UserIdentifier.String)
+	assert.Equal(t, []string{"user1"}, lockedUsers)
+
+	// A successful login resets the failures of the user, but not of the ip
+	_, err = detector.Observe(&Event{Type: EventTypeLoginSuccess, UserIdentifier: null.StringFrom("user1")})
+	assert.NoError(t, err)
+
+	escalated, err = detector.Observe(failure)
+	assert.NoError(t, err)
+	assert.Empty(t, escalated)
+
+	escalated, err = detector.Observe(failure)
+	assert.NoError(t, err)
+	assert.Len(t, escalated, 1)
+	assert.Contains(t, escalated[0].Reason.String, "source ip 10.0.0.1")
+	assert.Equal(t, []string{"user1"}, lockedUsers)
+
+	escalated, err = detector.Observe(failure)
+	assert.NoError(t, err)
+	assert.Len(t, escalated, 1)
+	assert.Contains(t, escalated[0].Reason.String, "user user1")
+	assert.Equal(t, []string{"user1", "user1"}, lockedUsers)
+
+	// Further failures within the window are not escalated again
+	escalated, err = detector.Observe(failure)
+	assert.NoError(t, err)
+	assert.Empty(t, escalated)
+
+	escalated, err = detector.Observe(&Event{Type: EventTypeUserCreated})
+	assert.NoError(t, err)
+	assert.Empty(t, escalated)
+}
@@ -0,0 +1,253 @@
+package siem
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// IFormatter defines the interface for serializing siem events
+type IFormatter interface {
+	Format(record *Record) ([]byte, error)
+}
+
+var cefSeverities = map[EventLevel]int{
+	EventLevelInfo:     3,
+	EventLevelWarn:     6,
+	EventLevelCritical: 10,
+}
+
+func cefSeverity(level EventLevel) int {
+	severity, ok := cefSeverities[level]
+	if !ok {
+		return cefSeverities[EventLevelCritical]
+	}
+
+	return severity
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+	leefValueEscaper    = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+)
+
+type formatField struct {
+	key   string
+	value string
+}
+
+// eventFields returns the optional fields of a siem event using the given keys
+func eventFields(event *Event, keyUser, keySourceIP, keyOutcome, keyReason, keyUserAgent string) []formatField {
+	fields := []formatField{}
+
+	if event.UserIdentifier.Valid {
+		fields = append(fields, formatField{keyUser, event.UserIdentifier.String})
+	}
+
+	if event.SourceIP.Valid {
+		fields = append(fields, formatField{keySourceIP, event.SourceIP.String})
+	}
+
+	if event.Outcome.Valid {
+		fields = append(fields, formatField{keyOutcome, event.Outcome.String})
+	}
+
+	if event.Reason.Valid {
+		fields = append(fields, formatField{keyReason, event.Reason.String})
+	}
+
+	if event.UserAgent.Valid {
+		fields = append(fields, formatField{keyUserAgent, event.UserAgent.String})
+	}
+
+	return fields
+}
+
+// CEFFormatter serializes siem events in the ArcSight Common Event Format (CEF)
+type CEFFormatter struct {
+	Vendor  string
+	Version string
+}
+
+var _ IFormatter = (*CEFFormatter)(nil)
+
+// Format serializes a siem event as CEF
+func (f *CEFFormatter) Format(record *Record) ([]byte, error) {
+	fields := []formatField{
+		{"rt", fmt.Sprintf("%d", record.Time.UnixMilli())},
+		{"msg", record.Message},
+	}
+
+	fields = append(fields, eventFields(record.Event, "suser", "src", "outcome", "reason", "requestClientApplication")...)
+
+	customStrings := []formatField{}
+
+	if record.Event.SourceRealIP.Valid {
+		customStrings = append(customStrings, formatField{"sourceRealIP", record.Event.SourceRealIP.String})
+	}
+
+	if record.Event.TargetResource.Valid {
+		customStrings = append(customStrings, formatField{"targetResource", record.Event.TargetResource.String})
+	}
+
+	if record.Event.SessionID.Valid {
+		customStrings = append(customStrings, formatField{"sessionID", record.Event.SessionID.String})
+	}
+
+	if record.Component != "" {
+		customStrings = append(customStrings, formatField{"component", record.Component})
+	}
+
+	for i, customString := range customStrings {
+		fields = append(
+			fields,
+			formatField{fmt.Sprintf("cs%d", i+1), customString.value},
+			formatField{fmt.Sprintf("cs%dLabel", i+1), customString.key},
+		)
+	}
+
+	if record.Hostname != "" {
+		fields = append(fields, formatField{"dvchost", record.Hostname})
+	}
+
+	extensions := make([]string, len(fields))
+	for i, field := range fields {
+		extensions[i] = fmt.Sprintf("%s=%s", field.key, cefExtensionEscaper.Replace(field.value))
+	}
+
+	name := record.Event.Type
+	definition, ok := GetEventTypeDefinition(record.Event.Type)
+	if ok && definition.Description != "" {
+		name = definition.Description
+	}
+
+	return []byte(fmt.Sprintf(
+		"CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		cefHeaderEscaper.Replace(f.Vendor),
+		cefHeaderEscaper.Replace(record.Product),
+		cefHeaderEscaper.Replace(f.Version),
+		cefHeaderEscaper.Replace(record.Event.Type),
+		cefHeaderEscaper.Replace(name),
+		cefSeverity(record.Level),
+		strings.Join(extensions, " "),
+	)), nil
+}
+
+// NewCEFFormatter creates a new initialized instance of CEFFormatter
+func NewCEFFormatter(vendor string, version string) *CEFFormatter {
+	return &CEFFormatter{
+		Vendor:  vendor,
+		Version: version,
+	}
+}
+
+// LEEFFormatter serializes siem events in the IBM QRadar Log Event Extended Format (LEEF 1.0)
+type LEEFFormatter struct {
+	Vendor  string
+	Version string
+}
+
+var _ IFormatter = (*LEEFFormatter)(nil)
+
+// Format serializes a siem event as LEEF
+func (f *LEEFFormatter) Format(record *Record) ([]byte, error) {
+	fields := []formatField{
+		{"devTime", record.Time.UTC().Format("Jan 02 2006 15:04:05.000")},
+		{"devTimeFormat", "MMM dd yyyy HH:mm:ss.SSS"},
+		{"sev", fmt.Sprintf("%d", cefSeverity(record.Level))},
+		{"cat", string(record.Level)},
+		{"msg", record.Message},
+	}
+
+	fields = append(fields, eventFields(record.Event, "usrName", "src", "outcome", "reason", "userAgent")...)
+
+	if record.Event.SourceRealIP.Valid {
+		fields = append(fields, formatField{"realSrc", record.Event.SourceRealIP.String})
+	}
+
+	if record.Event.TargetResource.Valid {
+		fields = append(fields, formatField{"resource", record.Event.TargetResource.String})
+	}
+
+	if record.Event.SessionID.Valid {
+		fields = append(fields, formatField{"sessionID", record.Event.SessionID.String})
+	}
+
+	if record.Component != "" {
+		fields = append(fields, formatField{"component", record.Component})
+	}
+
+	attributes := make([]string, len(fields))
+	for i, field := range fields {
+		attributes[i] = fmt.Sprintf("%s=%s", field.key, leefValueEscaper.Replace(field.value))
+	}
+
+	return []byte(fmt.Sprintf(
+		"LEEF:1.0|%s|%s|%s|%s|%s",
+		cefHeaderEscaper.Replace(f.Vendor),
+		cefHeaderEscaper.Replace(record.Product),
+		cefHeaderEscaper.Replace(f.Version),
+		cefHeaderEscaper.Replace(record.Event.Type),
+		strings.Join(attributes, "\t"),
+	)), nil
+}
+
+// NewLEEFFormatter creates a new initialized instance of LEEFFormatter
+func NewLEEFFormatter(vendor string, version string) *LEEFFormatter {
+	return &LEEFFormatter{
+		Vendor:  vendor,
+		Version: version,
+	}
+}
+
+type jsonRecord struct {
+	Time           time.Time  `json:"time"`
+	Type           EventType  `json:"type"`
+	Level          EventLevel `json:"level"`
+	Message        string     `json:"msg"`
+	Product        string     `json:"product,omitempty"`
+	Component      string     `json:"component,omitempty"`
+	Hostname       string     `json:"hostname,omitempty"`
+	UserIdentifier *string    `json:"user_identifier,omitempty"`
+	SourceIP       *string    `json:"source_ip,omitempty"`
+	SourceRealIP   *string    `json:"source_real_ip,omitempty"`
+	TargetResource *string    `json:"target_resource,omitempty"`
+	Outcome        *string    `json:"outcome,omitempty"`
+	Reason         *string    `json:"reason,omitempty"`
+	SessionID      *string    `json:"session_id,omitempty"`
+	UserAgent      *string    `json:"user_agent,omitempty"`
+}
+
+// JSONFormatter serializes siem events as single-line json objects
+type JSONFormatter struct {
+}
+
+var _ IFormatter = (*JSONFormatter)(nil)
+
+// Format serializes a siem event as json
+func (f *JSONFormatter) Format(record *Record) ([]byte, error) {
+	return json.Marshal(&jsonRecord{
+		Time:           record.Time.UTC(),
+		Type:           record.Event.Type,
+		Level:          record.Level,
+		Message:        record.Message,
+		Product:        record.Product,
+		Component:      record.Component,
+		Hostname:       record.Hostname,
+		UserIdentifier: record.Event.UserIdentifier.Ptr(),
+		SourceIP:       record.Event.SourceIP.Ptr(),
+		SourceRealIP:   record.Event.SourceRealIP.Ptr(),
+		TargetResource: record.Event.TargetResource.Ptr(),
+		Outcome:        record.Event.Outcome.Ptr(),
+		Reason:         record.Event.Reason.Ptr(),
+		SessionID:      record.Event.SessionID.Ptr(),
+		UserAgent:      record.Event.UserAgent.Ptr(),
+	})
+}
+
+// NewJSONFormatter creates a new initialized instance of JSONFormatter
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{}
+}
@@ -1,6 +1,12 @@
 package siem
 
-import "gopkg.in/guregu/null.v4"
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gopkg.in/guregu/null.v4"
+)
 
 // Fields used in logs for siem events
 const (
@@ -9,6 +15,11 @@ const (
 	EventFieldUserIdentifier = "siem_user_identifier"
 	EventFieldSourceIP       = "siem_source_ip"
 	EventFieldSourceRealIP   = "siem_source_real_ip"
+	EventFieldTargetResource = "siem_target_resource"
+	EventFieldOutcome        = "siem_outcome"
+	EventFieldReason         = "siem_reason"
+	EventFieldSessionID      = "siem_session_id"
+	EventFieldUserAgent      = "siem_user_agent"
 )
 
 // EventType specifies the type of the siem event
@@ -40,7 +51,15 @@ const (
 	EventLevelCritical EventLevel = "critical"
 )
 
-// EventLevels is a map of the matching level for each siem event type
+// Outcomes of siem events
+const (
+	EventOutcomeSuccess = "success"
+	EventOutcomeFailure = "failure"
+)
+
+// EventLevels is a map of the matching level for each builtin siem event type
+//
+// Deprecated: Use RegisterEventType() and GetEventTypeDefinition() instead
 var EventLevels = map[EventType]EventLevel{
 	EventTypeLoginSuccess:               EventLevelInfo,
 	EventTypeLoginFailed:                EventLevelWarn,
@@ -56,16 +75,117 @@ var EventLevels = map[EventType]EventLevel{
 	EventTypeAuthenticationFailedAttact: EventLevelCritical,
 }
 
+// EventTypeDefinition describes a registered siem event type
+type EventTypeDefinition struct {
+	Type        EventType
+	Level       EventLevel
+	Description string
+}
+
+var (
+	eventTypeDefinitions = map[EventType]*EventTypeDefinition{}
+	mutexEventTypes      sync.RWMutex
+)
+
+var builtinEventTypeDescriptions = map[EventType]string{
+	EventTypeLoginSuccess:               "A user logged in successfully",
+	EventTypeLoginFailed:                "A login attempt failed",
+	EventTypeLoginFailedAttact:          "Login attempts failed repeatedly (possible brute-force attack)",
+	EventTypeLogoutSuccess:              "A user logged out successfully",
+	EventTypeLogoutFailed:               "A logout failed",
+	EventTypeUserCreated:                "A user was created",
+	EventTypeUserDeleted:                "A user was deleted",
+	EventTypeUserLocked:                 "A user was locked",
+	EventTypeUserUnlocked:               "A user was unlocked",
+	EventTypeAuthenticationSuccess:      "A request was authenticated successfully",
+	EventTypeAuthenticationFailed:       "The authentication of a request failed",
+	EventTypeAuthenticationFailedAttact: "The authentication of a request failed in a suspicious way (possible attack)",
+}
+
+func init() {
+	for eventType, level := range EventLevels {
+		eventTypeDefinitions[eventType] = &EventTypeDefinition{
+			Type:        eventType,
+			Level:       level,
+			Description: builtinEventTypeDescriptions[eventType],
+		}
+	}
+}
+
+// RegisterEventType registers an application specific siem event type
+//
+// Registering an already existing type overwrites its level and description
+func RegisterEventType(eventType EventType, level EventLevel, description string) error {
+	if eventType == "" {
+		return fmt.Errorf("empty siem event type")
+	}
+
+	switch level {
+	case EventLevelInfo, EventLevelWarn, EventLevelCritical:
+	default:
+		return fmt.Errorf("invalid level '%s' for siem event type '%s'", level, eventType)
+	}
+
+	mutexEventTypes.Lock()
+	defer mutexEventTypes.Unlock()
+
+	eventTypeDefinitions[eventType] = &EventTypeDefinition{
+		Type:        eventType,
+		Level:       level,
+		Description: description,
+	}
+
+	return nil
+}
+
+// GetEventTypeDefinition returns the definition of a registered siem event type
+func GetEventTypeDefinition(eventType EventType) (*EventTypeDefinition, bool) {
+	mutexEventTypes.RLock()
+	defer mutexEventTypes.RUnlock()
+
+	definition, ok := eventTypeDefinitions[eventType]
+
+	return definition, ok
+}
+
+// GetEventTypeDefinitions returns the definitions of all registered siem event types
+// sorted by type
+func GetEventTypeDefinitions() []*EventTypeDefinition {
+	mutexEventTypes.RLock()
+	defer mutexEventTypes.RUnlock()
+
+	definitions := make([]*EventTypeDefinition, 0, len(eventTypeDefinitions))
+	for _, definition := range eventTypeDefinitions {
+		definitions = append(definitions, definition)
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Type < definitions[j].Type
+	})
+
+	return definitions
+}
+
 // Event is the basic struct for siem events
 type Event struct {
 	Type           EventType
 	UserIdentifier null.String
 	SourceIP       null.String
 	SourceRealIP   null.String
+	TargetResource null.String
+	Outcome        null.String
+	Reason         null.String
+	SessionID      null.String
+	UserAgent      null.String
 }
 
 // Level retuns the level to the siem event type (defaults to "critical")
 func (s *Event) Level() EventLevel {
+	definition, ok := GetEventTypeDefinition(s.Type)
+	if ok {
+		return definition.Level
+	}
+
 	level, ok := EventLevels[s.Type]
 	if !ok {
 		level = EventLevelCritical
@@ -73,3 +193,17 @@ func (s *Event) Level() EventLevel {
 
 	return level
 }
+
+// Validate checks if the siem event's type is registered
+func (s *Event) Validate() error {
+	if s.Type == "" {
+		return fmt.Errorf("empty siem event type")
+	}
+
+	_, ok := GetEventTypeDefinition(s.Type)
+	if !ok {
+		return fmt.Errorf("unregistered siem event type '%s'", s.Type)
+	}
+
+	return nil
+}
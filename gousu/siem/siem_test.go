@@ -0,0 +1,58 @@
+package siem
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventLevel(t *testing.T) {
+	evt := &Event{Type: EventTypeLoginFailed}
+	assert.Equal(t, EventLevelWarn, evt.Level())
+	assert.NoError(t, evt.Validate())
+
+	evt = &Event{Type: "unknown_event"}
+	assert.Equal(t, EventLevelCritical, evt.Level())
+	assert.Error(t, evt.Validate())
+}
+
+// restoreEventTypes restores the registered siem event types after the test
+func restoreEventTypes(t *testing.T) {
+	mutexEventTypes.RLock()
+	saved := map[EventType]*EventTypeDefinition{}
+	for eventType, definition := range eventTypeDefinitions {
+		saved[eventType] = definition
+	}
+	mutexEventTypes.RUnlock()
+
+	t.Cleanup(func() {
+		mutexEventTypes.Lock()
+		defer mutexEventTypes.Unlock()
+
+		eventTypeDefinitions = saved
+	})
+}
+
+func TestRegisterEventType(t *testing.T) {
+	restoreEventTypes(t)
+
+	assert.NoError(t, RegisterEventType("permission_changed", EventLevelWarn, "Permissions of a user were changed"))
+	assert.Error(t, RegisterEventType("data_exported", "unknown", "Data was exported"))
+	assert.Error(t, RegisterEventType("", EventLevelInfo, ""))
+
+	definition, ok := GetEventTypeDefinition("permission_changed")
+	assert.True(t, ok)
+	assert.Equal(t, EventLevelWarn, definition.Level)
+	assert.Equal(t, "Permissions of a user were changed", definition.Description)
+
+	evt := &Event{Type: "permission_changed"}
+	assert.Equal(t, EventLevelWarn, evt.Level())
+	assert.NoError(t, evt.Validate())
+
+	_, ok = GetEventTypeDefinition("data_exported")
+	assert.False(t, ok)
+
+	definitions := GetEventTypeDefinitions()
+	assert.Len(t, definitions, len(EventLevels)+1)
+	assert.Equal(t, EventTypeAuthenticationFailed, definitions[0].Type)
+}
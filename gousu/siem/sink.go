@@ -0,0 +1,194 @@
+package siem
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Record is a siem event including all its context written to sinks
+type Record struct {
+	Time      time.Time
+	Event     *Event
+	Level     EventLevel
+	Message   string
+	Product   string
+	Component string
+	Hostname  string
+}
+
+// ISink defines the interface of a destination for siem events
+//
+// Sinks receive all siem events independent of the application's log level
+type ISink interface {
+	Write(record *Record) error
+	Close() error
+}
+
+// WriterSink writes formatted siem events line by line to an io.Writer
+type WriterSink struct {
+	writer    io.Writer
+	formatter IFormatter
+	mutex     sync.Mutex
+}
+
+var _ ISink = (*WriterSink)(nil)
+
+// Write formats a siem event and writes it as a single line
+func (s *WriterSink) Write(record *Record) error {
+	line, err := s.formatter.Format(record)
+	if err != nil {
+		return fmt.Errorf("can't format siem event: %s", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err = s.writer.Write(append(line, '\n'))
+
+	return err
+}
+
+// Close closes the underlying writer if it implements io.Closer
+func (s *WriterSink) Close() error {
+	closer, ok := s.writer.(io.Closer)
+	if !ok {
+		return nil
+	}
+
+	return closer.Close()
+}
+
+// NewWriterSink creates a new initialized instance of WriterSink
+func NewWriterSink(writer io.Writer, formatter IFormatter) *WriterSink {
+	return &WriterSink{
+		writer:    writer,
+		formatter: formatter,
+	}
+}
+
+// NewFileSink creates a WriterSink appending siem events to a file
+func NewFileSink(path string, formatter IFormatter) (*WriterSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("can't open siem event file %s: %s", path, err)
+	}
+
+	return NewWriterSink(file, formatter), nil
+}
+
+// SyslogSink sends formatted siem events as RFC 5424 syslog messages
+//
+// The connection is reestablished on the next event after a write failed
+type SyslogSink struct {
+	network   string
+	address   string
+	formatter IFormatter
+	conn      net.Conn
+	mutex     sync.Mutex
+}
+
+var _ ISink = (*SyslogSink)(nil)
+
+// syslogFacilityAuthpriv is the syslog facility for security/authorization messages
+const syslogFacilityAuthpriv = 10
+
+var syslogSeverities = map[EventLevel]int{
+	EventLevelInfo:     6, // informational
+	EventLevelWarn:     4, // warning
+	EventLevelCritical: 2, // critical
+}
+
+func (s *SyslogSink) connect() error {
+	if s.conn != nil {
+		return nil
+	}
+
+	conn, err := net.DialTimeout(s.network, s.address, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("can't connect to syslog on %s://%s: %s", s.network, s.address, err)
+	}
+
+	s.conn = conn
+
+	return nil
+}
+
+// Write formats a siem event and sends it to syslog
+func (s *SyslogSink) Write(record *Record) error {
+	line, err := s.formatter.Format(record)
+	if err != nil {
+		return fmt.Errorf("can't format siem event: %s", err)
+	}
+
+	severity, ok := syslogSeverities[record.Level]
+	if !ok {
+		severity = syslogSeverities[EventLevelCritical]
+	}
+
+	hostname := record.Hostname
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	product := record.Product
+	if product == "" {
+		product = "-"
+	}
+
+	message := fmt.Sprintf(
+		"<%d>1 %s %s %s - - - %s\n",
+		syslogFacilityAuthpriv*8+severity,
+		record.Time.UTC().Format(time.RFC3339Nano),
+		hostname,
+		product,
+		line,
+	)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err = s.connect()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn.Write([]byte(message))
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+
+		return fmt.Errorf("can't write to syslog on %s://%s: %s", s.network, s.address, err)
+	}
+
+	return nil
+}
+
+// Close closes the connection to syslog
+func (s *SyslogSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+// NewSyslogSink creates a new initialized instance of SyslogSink
+//
+// The network can be "udp" or "tcp"
+func NewSyslogSink(network string, address string, formatter IFormatter) *SyslogSink {
+	return &SyslogSink{
+		network:   network,
+		address:   address,
+		formatter: formatter,
+	}
+}
@@ -0,0 +1,211 @@
+package siem
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BackPressurePolicy defines the behaviour of an AsyncSink if its buffer is full
+type BackPressurePolicy string
+
+// All back-pressure policies of AsyncSink
+const (
+	// BackPressureBlock blocks the logging goroutine until the buffer has space,
+	// a stalled target (e.g. an unreachable syslog server) stalls all logging goroutines
+	BackPressureBlock BackPressurePolicy = "block"
+	// BackPressureDropNewest discards the new event
+	BackPressureDropNewest BackPressurePolicy = "drop_newest"
+	// BackPressureDropOldest discards the oldest buffered event
+	BackPressureDropOldest BackPressurePolicy = "drop_oldest"
+)
+
+// DefaultBackPressurePolicy is used by NewAsyncSink if no policy is given
+const DefaultBackPressurePolicy = BackPressureDropOldest
+
+// DroppedReportInterval is the minimum interval between two reports of dropped
+// events passed to the error handler of an AsyncSink
+const DroppedReportInterval = 10 * time.Second
+
+// asyncSinkCloseTimeout is the maximum time Close waits for buffered events to be written
+var asyncSinkCloseTimeout = 10 * time.Second
+
+// AsyncSink buffers siem events and writes them to the target sink in a
+// separate goroutine
+type AsyncSink struct {
+	target       ISink
+	policy       BackPressurePolicy
+	buffer       chan *Record
+	errorHandler func(err error)
+	dropped      atomic.Int64
+	closed       bool
+	mutex        sync.RWMutex
+	closing      chan struct{}
+	closingOnce  sync.Once
+	done         chan struct{}
+	// reportedDropped and lastDroppedReport are guarded by mutexDroppedReport
+	reportedDropped    int64
+	lastDroppedReport  time.Time
+	mutexDroppedReport sync.Mutex
+}
+
+var _ ISink = (*AsyncSink)(nil)
+
+func (s *AsyncSink) run() {
+	defer close(s.done)
+
+	for record := range s.buffer {
+		err := s.target.Write(record)
+		if err != nil && s.errorHandler != nil {
+			s.errorHandler(err)
+		}
+	}
+}
+
+// reportDropped passes the number of events dropped since the last report to the
+// error handler, at most once per DroppedReportInterval
+func (s *AsyncSink) reportDropped() {
+	if s.errorHandler == nil {
+		return
+	}
+
+	s.mutexDroppedReport.Lock()
+
+	now := time.Now()
+	if now.Sub(s.lastDroppedReport) < DroppedReportInterval {
+		s.mutexDroppedReport.Unlock()
+
+		return
+	}
+
+	dropped := s.dropped.Load()
+	count := dropped - s.reportedDropped
+
+	s.reportedDropped = dropped
+	s.lastDroppedReport = now
+
+	s.mutexDroppedReport.Unlock()
+
+	s.errorHandler(fmt.Errorf("siem sink buffer full, dropped %d events", count))
+}
+
+// Write adds a siem event to the buffer, applying the back-pressure policy
+// if the buffer is full
+//
+// Dropped events are not returned as error but counted (see Dropped) and reported
+// to the error handler at most once per DroppedReportInterval.
+func (s *AsyncSink) Write(record *Record) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.closed {
+		return fmt.Errorf("siem sink closed")
+	}
+
+	switch s.policy {
+	case BackPressureDropNewest:
+		select {
+		case s.buffer <- record:
+		default:
+			s.dropped.Add(1)
+			s.reportDropped()
+		}
+
+		return nil
+	case BackPressureDropOldest:
+		for {
+			select {
+			case s.buffer <- record:
+				return nil
+			default:
+			}
+
+			select {
+			case <-s.buffer:
+				s.dropped.Add(1)
+				s.reportDropped()
+			default:
+			}
+		}
+	default:
+		// Aborted by Close, so a full buffer can't block closing the sink
+		select {
+		case s.buffer <- record:
+			return nil
+		case <-s.closing:
+			return fmt.Errorf("siem sink closed")
+		}
+	}
+}
+
+// Dropped returns the number of events discarded due to a full buffer
+func (s *AsyncSink) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close writes all buffered events to the target sink (waiting at most 10 seconds)
+// and closes it, the target is also closed if the timeout is exceeded
+func (s *AsyncSink) Close() error {
+	// Releases writers blocked on a full buffer
+	s.closingOnce.Do(func() {
+		close(s.closing)
+	})
+
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+
+		return nil
+	}
+
+	s.closed = true
+	close(s.buffer)
+	s.mutex.Unlock()
+
+	select {
+	case <-s.done:
+	case <-time.After(asyncSinkCloseTimeout):
+		return errors.Join(
+			fmt.Errorf("timeout flushing siem sink, %d events not written", len(s.buffer)),
+			s.target.Close(),
+		)
+	}
+
+	return s.target.Close()
+}
+
+// NewAsyncSink creates a new initialized instance of AsyncSink and starts its
+// writing goroutine
+//
+// The buffer size must be positive. An empty policy uses DefaultBackPressurePolicy,
+// unknown policies are rejected.
+// Errors of the target sink and reports of dropped events are passed to errorHandler
+// (may be nil)
+func NewAsyncSink(target ISink, bufferSize int, policy BackPressurePolicy, errorHandler func(err error)) (*AsyncSink, error) {
+	if bufferSize <= 0 {
+		return nil, fmt.Errorf("invalid siem sink buffer size %d", bufferSize)
+	}
+
+	switch policy {
+	case "":
+		policy = DefaultBackPressurePolicy
+	case BackPressureBlock, BackPressureDropNewest, BackPressureDropOldest:
+	default:
+		return nil, fmt.Errorf("unknown siem sink back-pressure policy '%s'", policy)
+	}
+
+	s := &AsyncSink{
+		target:       target,
+		policy:       policy,
+		buffer:       make(chan *Record, bufferSize),
+		errorHandler: errorHandler,
+		closing:      make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s, nil
+}
@@ -0,0 +1,208 @@
+package siem
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+)
+
+func newTestRecord() *Record {
+	return &Record{
+		Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Event: &Event{
+			Type:           EventTypeLoginFailed,
+			UserIdentifier: null.StringFrom("userid:5"),
+			SourceIP:       null.StringFrom("10.0.0.1"),
+			TargetResource: null.StringFrom("/api/v1/login"),
+		},
+		Level:     EventLevelWarn,
+		Message:   "Login failed: a=b|c",
+		Product:   "example",
+		Component: "controller.api",
+	}
+}
+
+func TestCEFFormatter(t *testing.T) {
+	line, err := NewCEFFormatter("indece", "1.0").Format(newTestRecord())
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"CEF:0|indece|example|1.0|login_failed|A login attempt failed|6|rt=1704164645000 msg=Login failed: a\\=b|c suser=userid:5 src=10.0.0.1 cs1=/api/v1/login cs1Label=targetResource cs2=controller.api cs2Label=component",
+		string(line),
+	)
+}
+
+func TestLEEFFormatter(t *testing.T) {
+	line, err := NewLEEFFormatter("indece", "1.0").Format(newTestRecord())
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(line), "LEEF:1.0|indece|example|1.0|login_failed|devTime=Jan 02 2024 03:04:05.000\t"))
+	assert.Contains(t, string(line), "\tusrName=userid:5\t")
+}
+
+func TestJSONFormatter(t *testing.T) {
+	line, err := NewJSONFormatter().Format(newTestRecord())
+
+	assert.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{"time":"2024-01-02T03:04:05Z","type":"login_failed","level":"warn","msg":"Login failed: a=b|c","product":"example","component":"controller.api","user_identifier":"userid:5","source_ip":"10.0.0.1","target_resource":"/api/v1/login"}`,
+		string(line),
+	)
+}
+
+type blockingSink struct {
+	records chan *Record
+	release chan struct{}
+	closed  chan struct{}
+}
+
+func (s *blockingSink) Write(record *Record) error {
+	<-s.release
+	s.records <- record
+
+	return nil
+}
+
+func (s *blockingSink) Close() error {
+	if s.closed != nil {
+		close(s.closed)
+	}
+
+	return nil
+}
+
+func TestAsyncSinkDropNewest(t *testing.T) {
+	target := &blockingSink{
+		records: make(chan *Record, 10),
+		release: make(chan struct{}),
+	}
+
+	reports := []error{}
+
+	sink, err := NewAsyncSink(target, 1, BackPressureDropNewest, func(err error) {
+		reports = append(reports, err)
+	})
+	require.NoError(t, err)
+
+	// First record is taken by the writing goroutine, second one is buffered
+	assert.NoError(t, sink.Write(newTestRecord()))
+	assert.Eventually(t, func() bool { return len(sink.buffer) == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, sink.Write(newTestRecord()))
+	assert.NoError(t, sink.Write(newTestRecord()))
+	assert.NoError(t, sink.Write(newTestRecord()))
+	assert.Equal(t, int64(2), sink.Dropped())
+
+	// Dropped events are reported once per interval
+	require.Len(t, reports, 1)
+	assert.EqualError(t, reports[0], "siem sink buffer full, dropped 1 events")
+
+	close(target.release)
+	assert.NoError(t, sink.Close())
+	assert.Len(t, target.records, 2)
+	assert.Error(t, sink.Write(newTestRecord()))
+}
+
+func TestNewAsyncSinkPolicy(t *testing.T) {
+	sink, err := NewAsyncSink(NewWriterSink(&bytes.Buffer{}, NewJSONFormatter()), 1, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, BackPressureDropOldest, sink.policy)
+	require.NoError(t, sink.Close())
+
+	_, err = NewAsyncSink(NewWriterSink(&bytes.Buffer{}, NewJSONFormatter()), 1, "drop_all", nil)
+	assert.Error(t, err)
+
+	_, err = NewAsyncSink(NewWriterSink(&bytes.Buffer{}, NewJSONFormatter()), 0, "", nil)
+	assert.Error(t, err)
+}
+
+func TestAsyncSinkCloseBlocked(t *testing.T) {
+	target := &blockingSink{
+		records: make(chan *Record, 10),
+		release: make(chan struct{}),
+	}
+	defer close(target.release)
+
+	sink, err := NewAsyncSink(target, 1, BackPressureBlock, nil)
+	require.NoError(t, err)
+
+	// First record is taken by the writing goroutine, second one is buffered
+	assert.NoError(t, sink.Write(newTestRecord()))
+	assert.Eventually(t, func() bool { return len(sink.buffer) == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, sink.Write(newTestRecord()))
+
+	blocked := make(chan error)
+
+	go func() {
+		blocked <- sink.Write(newTestRecord())
+	}()
+
+	asyncSinkCloseTimeout = 10 * time.Millisecond
+	defer func() {
+		asyncSinkCloseTimeout = 10 * time.Second
+	}()
+
+	closed := make(chan error)
+
+	go func() {
+		closed <- sink.Close()
+	}()
+
+	// The blocked write is aborted and Close reaches the timeout
+	select {
+	case err := <-blocked:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("write was not aborted by close")
+	}
+
+	select {
+	case err := <-closed:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("close was blocked by the full buffer")
+	}
+}
+
+func TestAsyncSinkCloseTimeout(t *testing.T) {
+	target := &blockingSink{
+		records: make(chan *Record, 10),
+		release: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	defer close(target.release)
+
+	sink, err := NewAsyncSink(target, 1, BackPressureDropOldest, nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, sink.Write(newTestRecord()))
+
+	asyncSinkCloseTimeout = 10 * time.Millisecond
+	defer func() {
+		asyncSinkCloseTimeout = 10 * time.Second
+	}()
+
+	assert.Error(t, sink.Close())
+
+	select {
+	case <-target.closed:
+	default:
+		t.Fatal("target was not closed")
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	sink := NewWriterSink(buf, NewJSONFormatter())
+
+	assert.NoError(t, sink.Write(newTestRecord()))
+	assert.NoError(t, sink.Write(newTestRecord()))
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+}
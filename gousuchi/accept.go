@@ -0,0 +1,119 @@
+package gousuchi
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept parses an Accept header into its media ranges
+func parseAccept(accept string) []*acceptRange {
+	ranges := []*acceptRange{}
+
+	for _, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		ranges = append(ranges, &acceptRange{
+			mediaType: mediaType,
+			quality:   quality,
+		})
+	}
+
+	return ranges
+}
+
+// matchQuality returns the quality of the most specific media range matching the offer
+func matchQuality(ranges []*acceptRange, offer string) float64 {
+	offerType, _, _ := strings.Cut(offer, "/")
+
+	quality := 0.0
+	specificity := -1
+
+	for _, acceptRange := range ranges {
+		rangeType, rangeSubtype, _ := strings.Cut(acceptRange.mediaType, "/")
+
+		currentSpecificity := -1
+
+		switch {
+		case acceptRange.mediaType == offer:
+			currentSpecificity = 2
+		case rangeType == offerType && rangeSubtype == "*":
+			currentSpecificity = 1
+		case acceptRange.mediaType == "*/*":
+			currentSpecificity = 0
+		}
+
+		if currentSpecificity > specificity {
+			specificity = currentSpecificity
+			quality = acceptRange.quality
+		}
+	}
+
+	return quality
+}
+
+// rankContentTypes returns the offers acceptable for the Accept header ordered by
+// their quality
+//
+// Offers are preferred in the given order if they have the same quality. All offers
+// are returned for an empty Accept header.
+func rankContentTypes(accept string, offers []string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return append([]string{}, offers...)
+	}
+
+	ranges := parseAccept(accept)
+
+	acceptable := []string{}
+	qualities := map[string]float64{}
+
+	for _, offer := range offers {
+		quality := matchQuality(ranges, offer)
+		if quality > 0 {
+			acceptable = append(acceptable, offer)
+			qualities[offer] = quality
+		}
+	}
+
+	sort.SliceStable(acceptable, func(i, j int) bool {
+		return qualities[acceptable[i]] > qualities[acceptable[j]]
+	})
+
+	return acceptable
+}
+
+// negotiateContentType selects the offer best matching the Accept header
+//
+// Offers are preferred in the given order if they have the same quality. The first offer
+// is returned for an empty Accept header, an empty string if no offer is acceptable.
+func negotiateContentType(accept string, offers []string) string {
+	acceptable := rankContentTypes(accept, offers)
+	if len(acceptable) == 0 {
+		return ""
+	}
+
+	return acceptable[0]
+}
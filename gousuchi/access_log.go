@@ -0,0 +1,181 @@
+package gousuchi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/indece-official/go-gousu/v2/gousu/metrics"
+)
+
+// Fields of the access log
+const (
+	AccessLogFieldMethod     = "method"
+	AccessLogFieldRoute      = "route"
+	AccessLogFieldStatus     = "status"
+	AccessLogFieldDuration   = "duration_ms"
+	AccessLogFieldBytes      = "bytes"
+	AccessLogFieldUserAgent  = "user_agent"
+	AccessLogFieldRemoteAddr = "remote_addr"
+)
+
+// Metrics recorded by Wrap
+const (
+	MetricRequestsTotal   = "http_requests_total"
+	MetricRequestDuration = "http_request_duration_seconds"
+)
+
+// RouteUnmatched is used as route for requests not routed by chi
+const RouteUnmatched = "unmatched"
+
+// MethodOther is used as method label of the metrics for non-standard methods
+const MethodOther = "OTHER"
+
+// AccessLogOptions configures the access log written by Wrap
+type AccessLogOptions struct {
+	// Disabled disables the logging of successful responses, errors are always logged
+	Disabled bool
+	// Fields are the fields added to the log records (defaults to all AccessLogField* fields)
+	Fields []string
+	// SkipRoutes are chi route patterns whose successful responses are not logged (e.g. "/health")
+	SkipRoutes []string
+}
+
+// DefaultAccessLogOptions returns the access log options including all fields
+func DefaultAccessLogOptions() AccessLogOptions {
+	return AccessLogOptions{
+		Fields: []string{
+			AccessLogFieldMethod,
+			AccessLogFieldRoute,
+			AccessLogFieldStatus,
+			AccessLogFieldDuration,
+			AccessLogFieldBytes,
+			AccessLogFieldUserAgent,
+			AccessLogFieldRemoteAddr,
+		},
+	}
+}
+
+type accessLogEntry struct {
+	request    *http.Request
+	route      string
+	statusCode int
+	bytes      int64
+	duration   time.Duration
+}
+
+// UseAccessLog configures the access log written by Wrap
+func (c *AbstractController) UseAccessLog(options AccessLogOptions) {
+	if len(options.Fields) == 0 {
+		options.Fields = DefaultAccessLogOptions().Fields
+	}
+
+	c.accessLogOptions = options
+}
+
+// UseMetrics sets the registry for the request metrics recorded by Wrap (defaults
+// to metrics.Default exposed by the actuator), nil disables the metrics
+func (c *AbstractController) UseMetrics(registry *metrics.Registry) {
+	c.metrics = registry
+}
+
+func getRoutePattern(r *http.Request) string {
+	routeContext := chi.RouteContext(r.Context())
+	if routeContext == nil {
+		return RouteUnmatched
+	}
+
+	pattern := routeContext.RoutePattern()
+	if pattern == "" {
+		return RouteUnmatched
+	}
+
+	return pattern
+}
+
+// getMetricMethod limits the method label to the standard methods, so arbitrary
+// methods don't create new time series
+func getMetricMethod(method string) string {
+	switch method {
+	case http.MethodGet,
+		http.MethodHead,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+		http.MethodConnect,
+		http.MethodOptions,
+		http.MethodTrace:
+		return method
+	default:
+		return MethodOther
+	}
+}
+
+func (c *AbstractController) recordMetrics(entry *accessLogEntry) {
+	if c.metrics == nil {
+		return
+	}
+
+	method := getMetricMethod(entry.request.Method)
+
+	c.metrics.Counter(
+		MetricRequestsTotal,
+		"Number of http requests by method, route and status",
+		metrics.Labels{
+			"method": method,
+			"route":  entry.route,
+			"status": strconv.Itoa(entry.statusCode),
+		},
+	).Inc()
+
+	c.metrics.Histogram(
+		MetricRequestDuration,
+		"Duration of http requests in seconds by method and route",
+		nil,
+		metrics.Labels{
+			"method": method,
+			"route":  entry.route,
+		},
+	).Observe(entry.duration.Seconds())
+}
+
+// isAccessLogged checks if a successful response should be logged
+func (c *AbstractController) isAccessLogged(entry *accessLogEntry) bool {
+	if c.accessLogOptions.Disabled {
+		return false
+	}
+
+	for _, route := range c.accessLogOptions.SkipRoutes {
+		if route == entry.route {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *AbstractController) getAccessLog(log *logger.Log, entry *accessLogEntry) *logger.Log {
+	for _, field := range c.accessLogOptions.Fields {
+		switch field {
+		case AccessLogFieldMethod:
+			log = log.RecordX(field, entry.request.Method)
+		case AccessLogFieldRoute:
+			log = log.RecordX(field, entry.route)
+		case AccessLogFieldStatus:
+			log = log.RecordX(field, entry.statusCode)
+		case AccessLogFieldDuration:
+			log = log.RecordX(field, float64(entry.duration.Microseconds())/1000)
+		case AccessLogFieldBytes:
+			log = log.RecordX(field, entry.bytes)
+		case AccessLogFieldUserAgent:
+			log = log.RecordX(field, c.sanitizeHeaderString(entry.request.UserAgent(), 256))
+		case AccessLogFieldRemoteAddr:
+			log = log.RecordX(field, entry.request.RemoteAddr)
+		}
+	}
+
+	return log
+}
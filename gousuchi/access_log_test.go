@@ -0,0 +1,89 @@
+package gousuchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/indece-official/go-gousu/v2/gousu/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapMetrics(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	registry := metrics.NewRegistry()
+	c.UseMetrics(registry)
+	c.UseAccessLog(AccessLogOptions{SkipRoutes: []string{"/health"}})
+
+	router := chi.NewRouter()
+	handler := c.Wrap(func(w http.ResponseWriter, r *http.Request) IResponse {
+		if chi.URLParam(r, "id") == "0" {
+			return NotFound(r, "User not found")
+		}
+
+		return Text(r, "OK")
+	})
+	router.Get("/users/{id}", handler)
+
+	for _, target := range []string{"/users/1", "/users/2", "/users/0"} {
+		writer := httptest.NewRecorder()
+		router.ServeHTTP(writer, httptest.NewRequest("GET", target, nil))
+	}
+
+	for _, method := range []string{"FOO", "BAR"} {
+		writer := httptest.NewRecorder()
+		handler(writer, httptest.NewRequest(method, "/users/1", nil))
+	}
+
+	assert.Equal(t, float64(2), registry.Counter(MetricRequestsTotal, "", metrics.Labels{
+		"method": "GET",
+		"route":  "/users/{id}",
+		"status": "200",
+	}).Value())
+	assert.Equal(t, float64(1), registry.Counter(MetricRequestsTotal, "", metrics.Labels{
+		"method": "GET",
+		"route":  "/users/{id}",
+		"status": "404",
+	}).Value())
+	// Non-standard methods share one label
+	assert.Equal(t, float64(2), registry.Counter(MetricRequestsTotal, "", metrics.Labels{
+		"method": MethodOther,
+		"route":  RouteUnmatched,
+		"status": "200",
+	}).Value())
+	assert.Equal(t, uint64(3), registry.Histogram(MetricRequestDuration, "", nil, metrics.Labels{
+		"method": "GET",
+		"route":  "/users/{id}",
+	}).Count())
+
+	builder := &strings.Builder{}
+	require.NoError(t, registry.WritePrometheus(builder))
+	assert.Contains(t, builder.String(), `http_requests_total{method="GET",route="/users/{id}",status="404"} 1`)
+}
+
+func TestWrapAccessLog(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+	c.UseMetrics(nil)
+	c.UseAccessLog(AccessLogOptions{SkipRoutes: []string{"/health"}})
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("User-Agent", "test\nagent")
+
+	entry := &accessLogEntry{
+		request:    req,
+		route:      "/health",
+		statusCode: http.StatusOK,
+		bytes:      2,
+	}
+
+	assert.False(t, c.isAccessLogged(entry))
+
+	entry.route = "/users"
+	assert.True(t, c.isAccessLogged(entry))
+	assert.NotNil(t, c.getAccessLog(c.GetLog(req), entry))
+}
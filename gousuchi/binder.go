@@ -0,0 +1,327 @@
+package gousuchi
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// MaxBindMultipartMemory is the maximum memory used for parsing multipart forms in Bind
+const MaxBindMultipartMemory = 32 << 20
+
+var typeTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setFieldValues parses string values into a field
+//
+// Supports strings, bools, numbers, types implementing encoding.TextUnmarshaler
+// (e.g. null.String, time.Time) and pointers and slices of them. Slices accept
+// multiple values, empty values are skipped.
+func setFieldValues(field reflect.Value, values []string) error {
+	if isSliceField(field) {
+		parts := []string{}
+		for _, value := range values {
+			if value != "" {
+				parts = append(parts, value)
+			}
+		}
+
+		slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			err := setFieldValue(slice.Index(i), part)
+			if err != nil {
+				return err
+			}
+		}
+
+		field.Set(slice)
+
+		return nil
+	}
+
+	return setFieldValue(field, values[0])
+}
+
+func isSliceField(field reflect.Value) bool {
+	return field.Kind() == reflect.Slice && !field.Addr().Type().Implements(typeTextUnmarshaler)
+}
+
+func setFieldValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		if value == "" {
+			field.Set(reflect.Zero(field.Type()))
+
+			return nil
+		}
+
+		ptr := reflect.New(field.Type().Elem())
+
+		err := setFieldValue(ptr.Elem(), value)
+		if err != nil {
+			return err
+		}
+
+		field.Set(ptr)
+
+		return nil
+	}
+
+	if field.Addr().Type().Implements(typeTextUnmarshaler) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// embeddedStruct returns the embedded struct of a field if it is flattened like
+// in the json encoding (embedded without json name), nil pointers are allocated
+func embeddedStruct(structField reflect.StructField, field reflect.Value) (reflect.Value, bool) {
+	jsonName, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+	if !structField.Anonymous || jsonName != "" {
+		return reflect.Value{}, false
+	}
+
+	for field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			if !field.CanSet() || field.Type().Elem().Kind() != reflect.Struct {
+				return reflect.Value{}, false
+			}
+
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+
+		field = field.Elem()
+	}
+
+	if field.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	return field, true
+}
+
+// bindValues sets all fields tagged with source from the lookup function
+//
+// Fields of embedded structs are bound as if they were fields of the struct. Slices
+// are bound from repeated query and form values (like QueryParamStringSlice) and from
+// comma-separated url params (like URLParamStringSlice).
+func bindValues(value reflect.Value, source string, lookup func(name string) []string) []*FieldError {
+	fieldErrors := []*FieldError{}
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		structField := valueType.Field(i)
+
+		embedded, ok := embeddedStruct(structField, value.Field(i))
+		if ok {
+			fieldErrors = append(fieldErrors, bindValues(embedded, source, lookup)...)
+
+			continue
+		}
+
+		if !structField.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(structField.Tag.Get(source), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		values := lookup(name)
+		if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+			continue
+		}
+
+		if source == FieldSourceURL && isSliceField(value.Field(i)) {
+			values = strings.Split(values[0], ",")
+		}
+
+		err := setFieldValues(value.Field(i), values)
+		if err != nil {
+			fieldErrors = append(fieldErrors, &FieldError{
+				Field:   name,
+				Source:  source,
+				Rule:    ValidationRuleType,
+				Message: fmt.Sprintf("invalid value: %s", err),
+			})
+		}
+	}
+
+	return fieldErrors
+}
+
+// decodeJSONBody decodes a json body into the target, values of the wrong type
+// are returned as field error
+func decodeJSONBody(body io.Reader, target interface{}) ([]*FieldError, error) {
+	err := json.NewDecoder(body).Decode(target)
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		return []*FieldError{
+			{
+				Field:   typeError.Field,
+				Source:  FieldSourceBody,
+				Rule:    ValidationRuleType,
+				Message: fmt.Sprintf("invalid value: expected %s", typeError.Type),
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("can't decode json body: %w", err)
+}
+
+// bindBody decodes a json or form body into the target
+func bindBody(request *http.Request, target interface{}, value reflect.Value) ([]*FieldError, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case string(ContentTypeApplicationJSON):
+		return decodeJSONBody(request.Body, target)
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		var err error
+
+		if mediaType == "multipart/form-data" {
+			err = request.ParseMultipartForm(MaxBindMultipartMemory)
+		} else {
+			err = request.ParseForm()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't parse form body: %w", err)
+		}
+
+		return bindValues(value, FieldSourceForm, func(name string) []string {
+			return request.PostForm[name]
+		}), nil
+	default:
+		return nil, nil
+	}
+}
+
+// Bind decodes a request into a struct and validates it
+//
+// The body is decoded as json or form (fields tagged with `form:"name"`) depending
+// on its content type. Afterwards fields tagged with `query:"name"` and `url:"name"`
+// are loaded from the url's query and chi's url params. Finally all fields are validated
+// via ValidateStruct.
+//
+// If the request is invalid a BadRequest-Response listing all field errors is returned,
+// else the response is nil
+func Bind(request *http.Request, target interface{}) IResponse {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return InternalServerError(request, "Can't bind request to %T, expected pointer to struct", target)
+	}
+
+	value = value.Elem()
+
+	fieldErrors, err := bindBody(request, target, value)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return RequestEntityTooLarge(request, "Request body exceeds limit of %d bytes", maxBytesError.Limit)
+		}
+
+		return BadRequest(request, "Invalid request body: %s", err)
+	}
+
+	// The json decoder stops at the first error, so the remaining fields are unset
+	if len(fieldErrors) > 0 {
+		return BadRequest(request, "Invalid request body: %s", joinFieldErrors(fieldErrors)).
+			WithFieldErrors(fieldErrors)
+	}
+
+	query := request.URL.Query()
+
+	fieldErrors = append(fieldErrors, bindValues(value, FieldSourceQuery, func(name string) []string {
+		return query[name]
+	})...)
+
+	fieldErrors = append(fieldErrors, bindValues(value, FieldSourceURL, func(name string) []string {
+		return []string{chi.URLParam(request, name)}
+	})...)
+
+	// Fields which couldn't be parsed are not validated again
+	invalidFields := map[string]bool{}
+	for _, fieldError := range fieldErrors {
+		invalidFields[fieldError.Field] = true
+	}
+
+	validationErrors, err := ValidateStruct(target)
+	if err != nil {
+		return InternalServerError(request, "Can't validate request: %s", err)
+	}
+
+	for _, validationError := range validationErrors {
+		if !invalidFields[validationError.Field] {
+			fieldErrors = append(fieldErrors, validationError)
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return BadRequest(request, "Invalid request: %s", joinFieldErrors(fieldErrors)).
+			WithFieldErrors(fieldErrors)
+	}
+
+	return nil
+}
+
+func joinFieldErrors(fieldErrors []*FieldError) string {
+	messages := []string{}
+
+	for _, fieldError := range fieldErrors {
+		messages = append(messages, fieldError.Error())
+	}
+
+	return strings.Join(messages, ", ")
+}
@@ -0,0 +1,204 @@
+package gousuchi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+)
+
+type testBindAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type testBindRequest struct {
+	ID      int64            `url:"id" validate:"min=1"`
+	Limit   int              `query:"limit" validate:"min=1,max=100"`
+	Tags    []string         `query:"tag" validate:"max=3"`
+	Filter  null.String      `query:"filter" validate:"len=3"`
+	Name    string           `json:"name" validate:"required,min=2,max=10"`
+	Role    string           `json:"role" validate:"enum=admin|user"`
+	Code    string           `json:"code" validate:"regex=^[A-Z]{2},[0-9]+$"`
+	Address *testBindAddress `json:"address"`
+}
+
+func TestBind(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users/12?limit=20&tag=a,b&tag=c&tag=&filter=abc", strings.NewReader(`{"name":"Test","role":"admin","code":"AB,12","address":{"city":"Berlin"}}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("id", "12")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+
+	body := &testBindRequest{}
+
+	resp := Bind(req, body)
+	require.Nil(t, resp)
+
+	assert.Equal(t, int64(12), body.ID)
+	assert.Equal(t, 20, body.Limit)
+	assert.Equal(t, []string{"a,b", "c"}, body.Tags)
+	assert.Equal(t, null.StringFrom("abc"), body.Filter)
+	assert.Equal(t, "Test", body.Name)
+	assert.Equal(t, "Berlin", body.Address.City)
+}
+
+func TestBindFieldErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users/x?limit=200&filter=abcd", strings.NewReader(`{"name":"T","role":"guest","code":"12","address":{}}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("id", "x")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+
+	resp := Bind(req, &testBindRequest{})
+	require.NotNil(t, resp)
+
+	writer := httptest.NewRecorder()
+	assert.Nil(t, resp.Write(writer))
+
+	assert.Equal(t, http.StatusBadRequest, writer.Result().StatusCode)
+	assert.Equal(t, "application/problem+json", writer.Header().Get("Content-Type"))
+
+	result := struct {
+		Errors []*FieldError `json:"errors"`
+	}{}
+	require.NoError(t, json.Unmarshal(writer.Body.Bytes(), &result))
+
+	rules := map[string]string{}
+	for _, fieldError := range result.Errors {
+		rules[fieldError.Field] = fieldError.Rule
+	}
+
+	assert.Equal(t, map[string]string{
+		"id":           ValidationRuleType,
+		"limit":        ValidationRuleMax,
+		"filter":       ValidationRuleLen,
+		"name":         ValidationRuleMin,
+		"role":         ValidationRuleEnum,
+		"code":         ValidationRuleRegex,
+		"address.city": ValidationRuleRequired,
+	}, rules)
+}
+
+type testBindPage struct {
+	Limit  int `query:"limit" validate:"required,max=100"`
+	Offset int `query:"offset"`
+}
+
+type testBindEmbeddedRequest struct {
+	testBindPage
+	testBindAddress
+	Name string `json:"name"`
+}
+
+func TestBindEmbedded(t *testing.T) {
+	target := testBindEmbeddedRequest{}
+
+	req := httptest.NewRequest(http.MethodPost, "/?limit=20&offset=40", strings.NewReader(`{"name":"Test","city":"Berlin"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp := Bind(req, &target)
+	require.Nil(t, resp)
+
+	assert.Equal(t, 20, target.Limit)
+	assert.Equal(t, 40, target.Offset)
+	assert.Equal(t, "Berlin", target.City)
+
+	req = httptest.NewRequest(http.MethodPost, "/?limit=200", strings.NewReader(`{"name":"Test"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp = Bind(req, &testBindEmbeddedRequest{})
+	require.NotNil(t, resp)
+
+	responseError, ok := resp.(*ResponseError)
+	require.True(t, ok)
+
+	fields := []string{}
+	for _, fieldError := range responseError.FieldErrors {
+		fields = append(fields, fieldError.Field)
+	}
+
+	assert.ElementsMatch(t, []string{"limit", "city"}, fields)
+}
+
+func TestBindURLSlice(t *testing.T) {
+	target := struct {
+		IDs []int64 `url:"ids"`
+	}{}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1,2", nil)
+
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("ids", "1,2")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+
+	resp := Bind(req, &target)
+	require.Nil(t, resp)
+
+	assert.Equal(t, []int64{1, 2}, target.IDs)
+}
+
+func TestBindJSONTypeError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users/12?limit=20", strings.NewReader(`{"name":1,"role":"admin"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("id", "12")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+
+	resp := Bind(req, &testBindRequest{})
+	require.NotNil(t, resp)
+
+	responseError, ok := resp.(*ResponseError)
+	require.True(t, ok)
+
+	// Only the decode error is reported, no required errors for the unset fields
+	require.Len(t, responseError.FieldErrors, 1)
+	assert.Equal(t, "name", responseError.FieldErrors[0].Field)
+	assert.Equal(t, ValidationRuleType, responseError.FieldErrors[0].Rule)
+}
+
+func TestBindForm(t *testing.T) {
+	target := struct {
+		Name  string `form:"name" validate:"required"`
+		Count uint   `form:"count"`
+	}{}
+
+	form := url.Values{}
+	form.Set("count", "3")
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp := Bind(req, &target)
+	require.NotNil(t, resp)
+
+	responseError, ok := resp.(*ResponseError)
+	require.True(t, ok)
+	require.Len(t, responseError.FieldErrors, 1)
+	assert.Equal(t, &FieldError{
+		Field:   "name",
+		Source:  FieldSourceForm,
+		Rule:    ValidationRuleRequired,
+		Message: "is required",
+	}, responseError.FieldErrors[0])
+	assert.Equal(t, uint(3), target.Count)
+}
+
+func TestValidateStructInvalidRule(t *testing.T) {
+	target := struct {
+		Name string `validate:"unknown"`
+	}{Name: "test"}
+
+	_, err := ValidateStruct(&target)
+	assert.Error(t, err)
+}
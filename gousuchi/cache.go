@@ -0,0 +1,107 @@
+package gousuchi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Presets for the Cache-Control header (see Response.WithCacheControl)
+const (
+	// CacheControlNoStore disables caching completely (e.g. for sensitive data)
+	CacheControlNoStore = "no-store"
+	// CacheControlNoCache allows caching, but the response must be revalidated
+	// (e.g. via ETag) before each use
+	CacheControlNoCache = "no-cache"
+)
+
+func maxAgeSeconds(maxAge time.Duration) int64 {
+	if maxAge < 0 {
+		return 0
+	}
+
+	return int64(maxAge / time.Second)
+}
+
+// CacheControlPrivate allows only the client to cache the response for maxAge
+func CacheControlPrivate(maxAge time.Duration) string {
+	return fmt.Sprintf("private, max-age=%d", maxAgeSeconds(maxAge))
+}
+
+// CacheControlPublic allows the client and shared caches (e.g. CDNs) to cache the
+// response for maxAge
+func CacheControlPublic(maxAge time.Duration) string {
+	return fmt.Sprintf("public, max-age=%d", maxAgeSeconds(maxAge))
+}
+
+// CacheControlImmutable allows all caches to use the response for maxAge without
+// revalidation (e.g. for versioned assets)
+func CacheControlImmutable(maxAge time.Duration) string {
+	return fmt.Sprintf("public, max-age=%d, immutable", maxAgeSeconds(maxAge))
+}
+
+// ComputeETag returns a strong ETag for the data
+func ComputeETag(data []byte) string {
+	hash := sha256.Sum256(data)
+
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// quoteETag quotes an ETag if it isn't quoted already (a weak ETag starts with W/)
+func quoteETag(etag string) string {
+	weak := strings.HasPrefix(etag, "W/")
+	opaque := strings.TrimPrefix(etag, "W/")
+
+	if !strings.HasPrefix(opaque, `"`) || !strings.HasSuffix(opaque, `"`) || len(opaque) < 2 {
+		opaque = `"` + strings.ReplaceAll(opaque, `"`, "") + `"`
+	}
+
+	if weak {
+		return "W/" + opaque
+	}
+
+	return opaque
+}
+
+// matchETag checks if an If-None-Match header matches the ETag (weak comparison)
+func matchETag(ifNoneMatch string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isNotModified checks if the client's cached version is still valid
+//
+// If-Modified-Since is ignored if the request contains If-None-Match (RFC 9110)
+func isNotModified(request *http.Request, etag string, lastModified time.Time) bool {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		return false
+	}
+
+	ifNoneMatch := request.Header.Get("If-None-Match")
+	if ifNoneMatch != "" {
+		return etag != "" && matchETag(ifNoneMatch, etag)
+	}
+
+	ifModifiedSince := request.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,131 @@
+package gousuchi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheControlPresets(t *testing.T) {
+	assert.Equal(t, "private, max-age=3600", CacheControlPrivate(time.Hour))
+	assert.Equal(t, "public, max-age=60", CacheControlPublic(time.Minute))
+	assert.Equal(t, "public, max-age=31536000, immutable", CacheControlImmutable(365*24*time.Hour))
+	assert.Equal(t, "private, max-age=0", CacheControlPrivate(-time.Second))
+}
+
+func TestQuoteETag(t *testing.T) {
+	assert.Equal(t, `"abc"`, quoteETag("abc"))
+	assert.Equal(t, `"abc"`, quoteETag(`"abc"`))
+	assert.Equal(t, `W/"abc"`, quoteETag("W/abc"))
+	assert.Equal(t, `W/"abc"`, quoteETag(`W/"abc"`))
+}
+
+func TestResponseETag(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	etag := ComputeETag(body)
+
+	writer := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	NewResponse(req, http.StatusOK, ContentTypeApplicationJSON, body).
+		WithBodyETag().
+		WithCacheControl(CacheControlNoCache).
+		Write(writer)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, etag, writer.Header().Get("ETag"))
+	assert.Equal(t, "no-cache", writer.Header().Get("Cache-Control"))
+	assert.Equal(t, "application/json", writer.Header().Get("Content-Type"))
+	assert.Equal(t, body, writer.Body.Bytes())
+
+	for _, ifNoneMatch := range []string{etag, `"other", W/` + etag, "*"} {
+		writer = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+
+		resp := JSON(req, map[string]int{"id": 1}).WithETag(etag)
+		resp.Write(writer)
+
+		assert.Equal(t, http.StatusNotModified, writer.Code, ifNoneMatch)
+		assert.Empty(t, writer.Body.Bytes())
+		assert.Equal(t, http.StatusNotModified, resp.servedStatusCode)
+	}
+
+	writer = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"other"`)
+	NewResponse(req, http.StatusOK, ContentTypeApplicationJSON, body).WithETag(etag).Write(writer)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+
+	// Conditional headers are ignored for unsafe methods
+	writer = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	NewResponse(req, http.StatusOK, ContentTypeApplicationJSON, body).WithETag(etag).Write(writer)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+}
+
+func TestResponseLastModified(t *testing.T) {
+	lastModified := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	writer := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-Modified-Since", lastModified.Format(http.TimeFormat))
+	Text(req, "test").WithLastModified(lastModified.Add(500 * time.Millisecond)).Write(writer)
+
+	assert.Equal(t, http.StatusNotModified, writer.Code)
+
+	writer = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-Modified-Since", lastModified.Add(-time.Hour).Format(http.TimeFormat))
+	Text(req, "test").WithLastModified(lastModified).Write(writer)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, lastModified.Format(http.TimeFormat), writer.Header().Get("Last-Modified"))
+	assert.Equal(t, "test", writer.Body.String())
+
+}
+
+func TestResponseNonSeekableStream(t *testing.T) {
+	lastModified := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	writer := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-Modified-Since", lastModified.Format(http.TimeFormat))
+	// Readers which aren't seekable can't be served via http.ServeContent
+	NewStreamResponse(req, http.StatusOK, ContentTypeTextPlain, struct{ io.Reader }{strings.NewReader("test")}).
+		WithLastModified(lastModified).
+		Write(writer)
+
+	assert.Equal(t, http.StatusNotModified, writer.Code)
+	assert.Equal(t, lastModified.Format(http.TimeFormat), writer.Header().Get("Last-Modified"))
+}
+
+func TestResponseRange(t *testing.T) {
+	writer := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/document.pdf", nil)
+	req.Header.Set("Range", "bytes=2-5")
+
+	resp := NewStreamResponse(req, http.StatusOK, ContentTypeApplicationPDF, strings.NewReader("0123456789"))
+	resp.Write(writer)
+
+	assert.Equal(t, http.StatusPartialContent, writer.Code)
+	assert.Equal(t, "bytes 2-5/10", writer.Header().Get("Content-Range"))
+	assert.Equal(t, "application/pdf", writer.Header().Get("Content-Type"))
+	assert.Equal(t, "2345", writer.Body.String())
+	assert.Equal(t, http.StatusPartialContent, resp.servedStatusCode)
+
+	writer = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/document.pdf", nil)
+	req.Header.Set("Range", "bytes=20-")
+
+	NewStreamResponse(req, http.StatusOK, ContentTypeApplicationPDF, strings.NewReader("0123456789")).Write(writer)
+
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, writer.Code)
+}
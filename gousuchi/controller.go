@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/indece-official/go-gousu/v2/gousu/metrics"
 )
 
 type contextKey string
@@ -26,26 +28,60 @@ type AbstractController struct {
 	host                 string
 	port                 int
 	error                error
+	accessLogOptions     AccessLogOptions
+	metrics              *metrics.Registry
+	done                 chan struct{}
+	doneOnce             sync.Once
+	stopOnce             sync.Once
+	webSockets           sync.WaitGroup
+	mutexWebSockets      sync.Mutex
 }
 
 type HandlerFunction func(w http.ResponseWriter, r *http.Request) IResponse
 
+// Wrap converts a HandlerFunction to a http.HandlerFunc
+//
+// The returned response is written and logged with the access log fields (see UseAccessLog),
+// the request is counted in the metrics (see UseMetrics)
 func (c *AbstractController) Wrap(clb HandlerFunction) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		resp := clb(w, r)
+		start := time.Now()
+		sw := newStatusWriter(w)
+
+		// Allows handlers to add log fields in place via WithExtra
+		r = c.withExtrasScope(r)
+
+		resp := clb(sw, r)
+
+		request := resp.GetRequest()
+		if request == nil {
+			request = r
+		}
 
-		log := c.GetLog(resp.GetRequest())
+		var logResp IResponse = resp
 
-		err := resp.Write(w)
+		err := resp.Write(sw)
 		if err != nil {
-			err.Write(w)
+			err.Write(sw)
+
+			logResp = err
+		}
+
+		entry := &accessLogEntry{
+			request:    request,
+			route:      getRoutePattern(r),
+			statusCode: sw.StatusCode(),
+			bytes:      sw.bytesWritten,
+			duration:   time.Since(start),
+		}
 
-			err.Log(log)
+		c.recordMetrics(entry)
 
+		if _, isError := logResp.(*ResponseError); !isError && !c.isAccessLogged(entry) {
 			return
 		}
 
-		resp.Log(log)
+		logResp.Log(c.getAccessLog(c.GetLog(request), entry))
 	}
 }
 
@@ -80,19 +116,28 @@ func (c *AbstractController) UseTlsConfig(tlsConfig *tls.Config) {
 	c.tlsConfig = tlsConfig
 }
 
-func (c *AbstractController) WithExtra(r *http.Request, key string, value interface{}) *http.Request {
-	ctx := r.Context()
+// withExtrasScope attaches a request scoped set of log fields to the request's context
+// if it has none yet
+func (c *AbstractController) withExtrasScope(r *http.Request) *http.Request {
+	if r.Context().Value(contextKeyExtras) != nil {
+		return r
+	}
 
-	extras, ok := ctx.Value(contextKeyExtras).(map[string]interface{})
-	if extras == nil && !ok {
-		extras := map[string]interface{}{}
+	ctx := logger.WithContext(r.Context(), nil)
 
-		extras[key] = value
+	return r.WithContext(context.WithValue(ctx, contextKeyExtras, true))
+}
 
-		return r.WithContext(context.WithValue(ctx, contextKeyExtras, extras))
-	}
+// WithExtra adds a log field to the request
+//
+// The field is added to all logs from GetLog and from services logging via
+// logger.Log.ContextX(ctx) with the request's context. It is added in place
+// (always the case in handlers called via Wrap), only the first call outside
+// of Wrap (e.g. in a middleware) returns a new request.
+func (c *AbstractController) WithExtra(r *http.Request, key string, value interface{}) *http.Request {
+	r = c.withExtrasScope(r)
 
-	extras[key] = value
+	logger.AddToContext(r.Context(), logger.Fields{key: value})
 
 	return r
 }
@@ -128,14 +173,7 @@ func (c *AbstractController) GetLog(r *http.Request) *logger.Log {
 		log = log.RecordX("x_user_agent_id", userAgentID)
 	}
 
-	extras, ok := r.Context().Value(contextKeyExtras).(map[string]interface{})
-	if extras != nil && ok {
-		for key, val := range extras {
-			log = log.RecordX(key, val)
-		}
-	}
-
-	return log
+	return log.ContextX(r.Context())
 }
 
 // Start starts the api server in a new go-func
@@ -177,15 +215,52 @@ func (c *AbstractController) Health() error {
 	return c.error
 }
 
-// Stop currently does nothing
+// Done returns a channel which is closed when the controller is stopped, so
+// long-running responses (e.g. SSE) can terminate before the server shuts down
+func (c *AbstractController) Done() <-chan struct{} {
+	return c.doneChannel()
+}
+
+// doneChannel creates the done channel on first use, so controllers not built
+// by NewAbstractController can be stopped too
+func (c *AbstractController) doneChannel() chan struct{} {
+	c.doneOnce.Do(func() {
+		c.done = make(chan struct{})
+	})
+
+	return c.done
+}
+
+// Stop closes the channel returned by Done, waits for all websockets to be closed
+// and shuts down the server gracefully
 func (c *AbstractController) Stop() error {
-	if c.server == nil {
-		return nil
-	}
+	c.stopOnce.Do(func() {
+		c.mutexWebSockets.Lock()
+		defer c.mutexWebSockets.Unlock()
+
+		close(c.doneChannel())
+	})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
+	webSocketsClosed := make(chan struct{})
+
+	go func() {
+		c.webSockets.Wait()
+		close(webSocketsClosed)
+	}()
+
+	select {
+	case <-webSocketsClosed:
+	case <-ctx.Done():
+		c.log.Warnf("Timeout while waiting for websockets to be closed")
+	}
+
+	if c.server == nil {
+		return nil
+	}
+
 	return c.server.Shutdown(ctx)
 }
 
@@ -199,5 +274,7 @@ func NewAbstractController(
 		host:                 "",
 		port:                 0,
 		tlsConfig:            nil,
+		accessLogOptions:     DefaultAccessLogOptions(),
+		metrics:              metrics.Default,
 	}
 }
@@ -0,0 +1,48 @@
+package gousuchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithExtra(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	fields := logger.Fields{}
+
+	handler := c.RequestIDMiddleware()(http.HandlerFunc(c.Wrap(func(w http.ResponseWriter, r *http.Request) IResponse {
+		// The returned request can be ignored inside of Wrap
+		c.WithExtra(r, "user_id", 12)
+
+		fields = logger.FromContext(r.Context())
+
+		return JSON(r, "ok")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderRequestID, "abc-123")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, logger.Fields{LogFieldRequestID: "abc-123", "user_id": 12}, fields)
+}
+
+func TestStopWithoutConstructor(t *testing.T) {
+	c := &AbstractController{}
+
+	done := c.Done()
+	assert.NotNil(t, done)
+
+	assert.NoError(t, c.Stop())
+	assert.NoError(t, c.Stop())
+
+	select {
+	case <-done:
+	default:
+		t.Fatal("done was not closed on stop")
+	}
+}
@@ -0,0 +1,423 @@
+package gousuchi
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrUnsupportedValue is returned by encoders which can't encode a value, Negotiate
+// tries the next acceptable encoder in this case
+var ErrUnsupportedValue = errors.New("unsupported value")
+
+// IEncoder encodes response bodies for Negotiate
+type IEncoder interface {
+	ContentType() ContentType
+	Encode(value interface{}) ([]byte, error)
+}
+
+// JSONEncoder encodes values as json
+type JSONEncoder struct{}
+
+var _ IEncoder = (*JSONEncoder)(nil)
+
+func (e *JSONEncoder) ContentType() ContentType {
+	return ContentTypeApplicationJSON
+}
+
+func (e *JSONEncoder) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// XMLEncoder encodes values as xml, slices are wrapped in an element named
+// ListElement (defaults to "items")
+type XMLEncoder struct {
+	ListElement string
+}
+
+var _ IEncoder = (*XMLEncoder)(nil)
+
+func (e *XMLEncoder) ContentType() ContentType {
+	return ContentTypeApplicationXML
+}
+
+func (e *XMLEncoder) Encode(value interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+
+	encoder := xml.NewEncoder(buf)
+
+	var err error
+
+	list := reflect.Indirect(reflect.ValueOf(value))
+	if (list.Kind() == reflect.Slice || list.Kind() == reflect.Array) && list.Type().Elem().Kind() != reflect.Uint8 {
+		listElement := e.ListElement
+		if listElement == "" {
+			listElement = "items"
+		}
+
+		start := xml.StartElement{Name: xml.Name{Local: listElement}}
+
+		err = encoder.EncodeToken(start)
+		for i := 0; err == nil && i < list.Len(); i++ {
+			err = encoder.Encode(list.Index(i).Interface())
+		}
+
+		if err == nil {
+			err = encoder.EncodeToken(start.End())
+		}
+	} else {
+		err = encoder.Encode(value)
+	}
+
+	if err == nil {
+		err = encoder.Flush()
+	}
+
+	if err != nil {
+		var unsupportedTypeError *xml.UnsupportedTypeError
+		if errors.As(err, &unsupportedTypeError) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedValue, err)
+		}
+
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// CSVEncoder encodes slices of structs as csv with a header row
+//
+// The column names are taken from the csv tag, the json tag or the field's name,
+// fields tagged with `csv:"-"` are skipped. Nested values are encoded as json.
+type CSVEncoder struct {
+	// Comma is the field delimiter (defaults to ',')
+	Comma rune
+}
+
+var _ IEncoder = (*CSVEncoder)(nil)
+
+func (e *CSVEncoder) ContentType() ContentType {
+	return ContentTypeTextCSV
+}
+
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+func csvColumns(structType reflect.Type) []*csvColumn {
+	columns := []*csvColumn{}
+
+	for _, field := range reflect.VisibleFields(structType) {
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("csv"), ",")
+		if name == "" {
+			name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+		}
+
+		if name == "-" {
+			continue
+		}
+
+		// Fields of embedded structs are included directly
+		if field.Anonymous && name == "" && reflect.Indirect(reflect.New(field.Type)).Kind() == reflect.Struct {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		columns = append(columns, &csvColumn{
+			name:  name,
+			index: field.Index,
+		})
+	}
+
+	return columns
+}
+
+func formatCSVValue(value reflect.Value) (string, error) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return "", nil
+		}
+
+		value = value.Elem()
+	}
+
+	if !value.IsValid() {
+		return "", nil
+	}
+
+	raw := value.Interface()
+
+	if valuer, ok := raw.(driver.Valuer); ok {
+		driverValue, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+
+		if driverValue == nil {
+			return "", nil
+		}
+
+		raw = driverValue
+	}
+
+	switch typedValue := raw.(type) {
+	case string:
+		return typedValue, nil
+	case time.Time:
+		return typedValue.Format(time.RFC3339), nil
+	case []byte:
+		return string(typedValue), nil
+	case encoding.TextMarshaler:
+		text, err := typedValue.MarshalText()
+
+		return string(text), err
+	}
+
+	switch value.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		data, err := json.Marshal(raw)
+
+		return string(data), err
+	default:
+		return fmt.Sprint(raw), nil
+	}
+}
+
+func (e *CSVEncoder) Encode(value interface{}) ([]byte, error) {
+	list := reflect.Indirect(reflect.ValueOf(value))
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%w: csv requires a slice of structs, got %T", ErrUnsupportedValue, value)
+	}
+
+	elemType := list.Type().Elem()
+	for elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+
+	if elemType.Kind() != reflect.Struct || elemType == typeTime {
+		return nil, fmt.Errorf("%w: csv requires a slice of structs, got %T", ErrUnsupportedValue, value)
+	}
+
+	columns := csvColumns(elemType)
+
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+	if e.Comma != 0 {
+		writer.Comma = e.Comma
+	}
+
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.name
+	}
+
+	err := writer.Write(record)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < list.Len(); i++ {
+		elem := list.Index(i)
+		for elem.Kind() == reflect.Pointer && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+
+		for j, column := range columns {
+			record[j] = ""
+
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+
+			field, err := elem.FieldByIndexErr(column.index)
+			if err != nil {
+				// Field of a nil embedded struct
+				continue
+			}
+
+			record[j], err = formatCSVValue(field)
+			if err != nil {
+				return nil, fmt.Errorf("can't encode column %s of row %d: %w", column.name, i, err)
+			}
+		}
+
+		err = writer.Write(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+
+	err = writer.Error()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MsgPackEncoder encodes values as MessagePack, the json tags define the field names
+type MsgPackEncoder struct{}
+
+var _ IEncoder = (*MsgPackEncoder)(nil)
+
+func (e *MsgPackEncoder) ContentType() ContentType {
+	return ContentTypeApplicationMsgPack
+}
+
+func (e *MsgPackEncoder) Encode(value interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	encoder := msgpack.NewEncoder(buf)
+	encoder.SetCustomStructTag("json")
+
+	err := encoder.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ProtobufEncoder encodes protobuf messages (proto.Message) in the binary wire format
+type ProtobufEncoder struct{}
+
+var _ IEncoder = (*ProtobufEncoder)(nil)
+
+func (e *ProtobufEncoder) ContentType() ContentType {
+	return ContentTypeApplicationProtobuf
+}
+
+func (e *ProtobufEncoder) Encode(value interface{}) ([]byte, error) {
+	message, ok := value.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: protobuf requires a proto.Message, got %T", ErrUnsupportedValue, value)
+	}
+
+	return proto.Marshal(message)
+}
+
+// EncoderRegistry holds the encoders available for Negotiate
+type EncoderRegistry struct {
+	encoders []IEncoder
+	mutex    sync.RWMutex
+}
+
+// Register adds an encoder, it replaces a registered encoder with the same content type
+//
+// Encoders are preferred in the order of their registration if the client accepts
+// multiple content types with the same quality.
+func (r *EncoderRegistry) Register(encoder IEncoder) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, registeredEncoder := range r.encoders {
+		if registeredEncoder.ContentType() == encoder.ContentType() {
+			r.encoders[i] = encoder
+
+			return
+		}
+	}
+
+	r.encoders = append(r.encoders, encoder)
+}
+
+// Negotiate creates a response with the object encoded by the encoder best matching
+// the Accept header of the request
+//
+// The first registered encoder is used if the request has no Accept header. Encoders
+// returning ErrUnsupportedValue are skipped. If no encoder is acceptable a NotAcceptable-
+// Response is returned.
+func (r *EncoderRegistry) Negotiate(request *http.Request, obj interface{}) *Response {
+	r.mutex.RLock()
+
+	encoders := map[string]IEncoder{}
+	offers := make([]string, 0, len(r.encoders))
+
+	for _, encoder := range r.encoders {
+		encoders[string(encoder.ContentType())] = encoder
+		offers = append(offers, string(encoder.ContentType()))
+	}
+
+	r.mutex.RUnlock()
+
+	accept := request.Header.Get("Accept")
+
+	for _, contentType := range rankContentTypes(accept, offers) {
+		body, err := encoders[contentType].Encode(obj)
+		if errors.Is(err, ErrUnsupportedValue) {
+			continue
+		}
+		if err != nil {
+			return &Response{
+				Request:       request,
+				responseError: InternalServerError(request, "Can't encode response as %s: %s", contentType, err),
+			}
+		}
+
+		return &Response{
+			Request:     request,
+			StatusCode:  http.StatusOK,
+			ContentType: ContentType(contentType),
+			Body:        body,
+			Header: http.Header{
+				"Vary": []string{"Accept"},
+			},
+		}
+	}
+
+	return &Response{
+		Request:       request,
+		responseError: NotAcceptable(request, "Can't encode %T for Accept '%s' (supported: %s)", obj, accept, strings.Join(offers, ", ")),
+	}
+}
+
+// NewEncoderRegistry creates a new initialized instance of EncoderRegistry
+func NewEncoderRegistry(encoders ...IEncoder) *EncoderRegistry {
+	r := &EncoderRegistry{}
+
+	for _, encoder := range encoders {
+		r.Register(encoder)
+	}
+
+	return r
+}
+
+// DefaultEncoders are the encoders used by Negotiate, custom encoders can be added via
+// DefaultEncoders.Register
+var DefaultEncoders = NewEncoderRegistry(
+	&JSONEncoder{},
+	&XMLEncoder{},
+	&CSVEncoder{},
+	&MsgPackEncoder{},
+	&ProtobufEncoder{},
+)
+
+// Negotiate creates a response with the object encoded as json, xml, csv, MessagePack
+// or protobuf depending on the Accept header of the request (see EncoderRegistry.Negotiate)
+func Negotiate(request *http.Request, obj interface{}) *Response {
+	return DefaultEncoders.Negotiate(request, obj)
+}
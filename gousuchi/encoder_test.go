@@ -0,0 +1,161 @@
+package gousuchi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/guregu/null.v4"
+)
+
+type testEncoderBase struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type testEncoderItem struct {
+	testEncoderBase
+	Name     string      `json:"name" xml:"name"`
+	Price    float64     `csv:"price_eur" json:"price"`
+	Comment  null.String `json:"comment"`
+	Created  time.Time   `json:"created"`
+	Tags     []string    `json:"tags"`
+	Internal string      `json:"-"`
+}
+
+func TestCSVEncoder(t *testing.T) {
+	items := []*testEncoderItem{
+		{
+			testEncoderBase: testEncoderBase{ID: uuid.MustParse("8f5b7d5c-2c1a-4d8e-9b0a-3c4d5e6f7a8b")},
+			Name:            "Test, Inc.",
+			Price:           1.5,
+			Comment:         null.StringFrom("new"),
+			Created:         time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+			Tags:            []string{"a", "b"},
+		},
+		nil,
+	}
+
+	data, err := (&CSVEncoder{}).Encode(items)
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		"id,name,price_eur,comment,created,tags\n"+
+			"8f5b7d5c-2c1a-4d8e-9b0a-3c4d5e6f7a8b,\"Test, Inc.\",1.5,new,2024-01-31T12:00:00Z,\"[\"\"a\"\",\"\"b\"\"]\"\n"+
+			",,,,,\n",
+		string(data),
+	)
+
+	_, err = (&CSVEncoder{}).Encode(&testEncoderItem{})
+	assert.ErrorIs(t, err, ErrUnsupportedValue)
+
+	_, err = (&CSVEncoder{}).Encode([]string{"a"})
+	assert.ErrorIs(t, err, ErrUnsupportedValue)
+}
+
+func TestXMLEncoder(t *testing.T) {
+	type item struct {
+		Name string `xml:"name"`
+	}
+
+	data, err := (&XMLEncoder{ListElement: "list"}).Encode([]*item{{Name: "a"}, {Name: "b"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<list><item><name>a</name></item><item><name>b</name></item></list>", string(data))
+
+	_, err = (&XMLEncoder{}).Encode(map[string]int{"a": 1})
+	assert.ErrorIs(t, err, ErrUnsupportedValue)
+}
+
+func TestNegotiate(t *testing.T) {
+	items := []*testEncoderItem{{Name: "a", Price: 2}}
+
+	resp := Negotiate(httptest.NewRequest(http.MethodGet, "/items", nil), items)
+	require.Nil(t, resp.responseError)
+	assert.Equal(t, ContentTypeApplicationJSON, resp.ContentType)
+	assert.Equal(t, "Accept", resp.Header.Get("Vary"))
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept", "text/csv")
+	resp = Negotiate(req, items)
+	require.Nil(t, resp.responseError)
+	assert.Equal(t, ContentTypeTextCSV, resp.ContentType)
+
+	req = httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept", "application/xml;q=0.5, application/msgpack")
+	resp = Negotiate(req, items)
+	require.Nil(t, resp.responseError)
+	assert.Equal(t, ContentTypeApplicationMsgPack, resp.ContentType)
+
+	decoded := []map[string]interface{}{}
+	require.NoError(t, msgpack.Unmarshal(resp.Body, &decoded))
+	assert.Equal(t, "a", decoded[0]["name"])
+
+	// Encoders not supporting the value are skipped
+	req = httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept", "text/csv, application/json;q=0.1")
+	resp = Negotiate(req, map[string]int{"a": 1})
+	require.Nil(t, resp.responseError)
+	assert.Equal(t, ContentTypeApplicationJSON, resp.ContentType)
+
+	req = httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+	resp = Negotiate(req, wrapperspb.String("test"))
+	require.Nil(t, resp.responseError)
+	assert.Equal(t, ContentTypeApplicationProtobuf, resp.ContentType)
+
+	message := &wrapperspb.StringValue{}
+	require.NoError(t, proto.Unmarshal(resp.Body, message))
+	assert.Equal(t, "test", message.GetValue())
+
+	writer := httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+	errResp := Negotiate(req, items).Write(writer)
+	require.NotNil(t, errResp)
+	errResp.Write(writer)
+
+	assert.Equal(t, http.StatusNotAcceptable, writer.Code)
+
+	writer = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept", "image/png")
+	errResp = Negotiate(req, items).Write(writer)
+	require.NotNil(t, errResp)
+	errResp.Write(writer)
+
+	assert.Equal(t, http.StatusNotAcceptable, writer.Code)
+}
+
+type testUpperEncoder struct{}
+
+func (e *testUpperEncoder) ContentType() ContentType {
+	return ContentTypeApplicationJSON
+}
+
+func (e *testUpperEncoder) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{"DATA": value})
+}
+
+func TestEncoderRegistry(t *testing.T) {
+	registry := NewEncoderRegistry(&CSVEncoder{}, &JSONEncoder{})
+	registry.Register(&testUpperEncoder{})
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept", "*/*")
+	resp := registry.Negotiate(req, []*testEncoderItem{})
+	assert.Equal(t, ContentTypeTextCSV, resp.ContentType)
+
+	req = httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept", "application/json")
+	resp = registry.Negotiate(req, 1)
+	assert.Equal(t, `{"DATA":1}`, string(resp.Body))
+}
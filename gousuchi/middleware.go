@@ -0,0 +1,147 @@
+package gousuchi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+)
+
+// Middleware is a chi-compatible http middleware
+type Middleware func(next http.Handler) http.Handler
+
+// HeaderRequestID is the header containing the id of a request
+const HeaderRequestID = "X-Request-ID"
+
+var regexpRequestID = regexp.MustCompile(`^[a-zA-Z0-9._\-]{1,64}$`)
+
+func generateRequestID() string {
+	data := make([]byte, 16)
+
+	_, err := rand.Read(data)
+	if err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(data)
+}
+
+// GetRequestID returns the id of a request set by RequestIDMiddleware
+func GetRequestID(r *http.Request) string {
+	requestID, _ := logger.FromContext(r.Context())[LogFieldRequestID].(string)
+
+	return requestID
+}
+
+// RequestIDMiddleware propagates the request id from the X-Request-ID header or
+// generates a new one
+//
+// The request id is returned in the X-Request-ID header, attached as log field
+// "request_id" to the request's context and included in error responses
+func (c *AbstractController) RequestIDMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(HeaderRequestID)
+			if !regexpRequestID.MatchString(requestID) {
+				if requestID != "" {
+					c.GetLog(r).Debugf("Replacing invalid request id from header %s", HeaderRequestID)
+				}
+
+				requestID = generateRequestID()
+			}
+
+			w.Header().Set(HeaderRequestID, requestID)
+
+			next.ServeHTTP(w, c.WithExtra(r, LogFieldRequestID, requestID))
+		})
+	}
+}
+
+// RecoverMiddleware recovers from panics in handlers and returns an InternalServerError
+//
+// The panic and the stack trace are logged
+func (c *AbstractController) RecoverMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := newStatusWriter(w)
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				resp := InternalServerError(r, "Panic in handler: %v\n%s", rec, debug.Stack())
+
+				resp.Log(c.GetLog(r))
+
+				if !sw.wroteHeader {
+					resp.Write(sw)
+				}
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// BodyLimitMiddleware limits the size of request bodies
+//
+// Requests with a larger Content-Length are rejected with a RequestEntityTooLarge response,
+// reading more bytes from the body fails with an *http.MaxBytesError
+func (c *AbstractController) BodyLimitMiddleware(maxBytes int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				resp := RequestEntityTooLarge(r, "Request body of %d bytes exceeds limit of %d bytes", r.ContentLength, maxBytes)
+
+				resp.Write(w)
+				resp.Log(c.GetLog(r))
+
+				return
+			}
+
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TimeoutMiddleware cancels the request's context after the timeout
+//
+// Handlers must stop processing when the context is done. If the handler didn't
+// write a response until then, a ServiceUnavailable response is returned.
+// Use it per route via chi's With, e.g. router.With(c.TimeoutMiddleware(5*time.Second)).Get(...)
+func (c *AbstractController) TimeoutMiddleware(timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			r = r.WithContext(ctx)
+			sw := newStatusWriter(w)
+
+			next.ServeHTTP(sw, r)
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && !sw.wroteHeader {
+				resp := ServiceUnavailable(r, "Request timed out after %s", timeout)
+
+				resp.Write(sw)
+				resp.Log(c.GetLog(r))
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package gousuchi
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+)
+
+// DefaultCompressContentTypes are the content types compressed by CompressMiddleware
+// if no content types are specified
+var DefaultCompressContentTypes = []string{
+	"text/*",
+	string(ContentTypeApplicationJSON),
+	string(ContentTypeApplicationProblemJSON),
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+}
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+type compressWriter struct {
+	http.ResponseWriter
+	log          *logger.Log
+	encoding     string
+	contentTypes []string
+	encoder      io.WriteCloser
+	wroteHeader  bool
+}
+
+func (w *compressWriter) isCompressible() bool {
+	if w.Header().Get("Content-Encoding") != "" || w.Header().Get("Content-Range") != "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	for _, contentType := range w.contentTypes {
+		prefix, isWildcard := strings.CutSuffix(contentType, "*")
+		if (isWildcard && strings.HasPrefix(mediaType, prefix)) || mediaType == contentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *compressWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(statusCode)
+
+		return
+	}
+
+	w.wroteHeader = true
+
+	if statusCode != http.StatusNoContent &&
+		statusCode != http.StatusNotModified &&
+		statusCode != http.StatusPartialContent &&
+		w.isCompressible() {
+		w.Header().Set("Content-Encoding", w.encoding)
+		w.Header().Del("Content-Length")
+
+		switch w.encoding {
+		case encodingBrotli:
+			w.encoder = brotli.NewWriterLevel(w.ResponseWriter, brotli.DefaultCompression)
+		default:
+			w.encoder = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(data))
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush flushes the encoder and the underlying writer
+func (w *compressWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		err := flusher.Flush()
+		if err != nil {
+			w.log.Warnf("Can't flush %s encoder: %s", w.encoding, err)
+		}
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hijacks the underlying connection if supported (required for websockets)
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+
+	w.wroteHeader = true
+
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) close() {
+	if w.encoder == nil {
+		return
+	}
+
+	err := w.encoder.Close()
+	if err != nil {
+		w.log.Warnf("Can't close %s encoder: %s", w.encoding, err)
+	}
+}
+
+// selectEncoding selects brotli or gzip from the Accept-Encoding header, brotli is preferred
+func selectEncoding(acceptEncoding string) string {
+	qualities := map[string]float64{}
+
+	for _, acceptRange := range parseAccept(strings.ReplaceAll(acceptEncoding, " ", "")) {
+		qualities[acceptRange.mediaType] = acceptRange.quality
+	}
+
+	encoding := ""
+	quality := 0.0
+
+	for _, offer := range []string{encodingBrotli, encodingGzip} {
+		offerQuality, ok := qualities[offer]
+		if !ok {
+			offerQuality, ok = qualities["*"]
+		}
+
+		if ok && offerQuality > quality {
+			encoding = offer
+			quality = offerQuality
+		}
+	}
+
+	return encoding
+}
+
+// CompressMiddleware compresses responses with brotli or gzip depending on the
+// Accept-Encoding header of the request
+//
+// Only responses with one of the content types are compressed (defaults to
+// DefaultCompressContentTypes), a content type may end with "*" to match a prefix
+func (c *AbstractController) CompressMiddleware(contentTypes ...string) Middleware {
+	if len(contentTypes) == 0 {
+		contentTypes = DefaultCompressContentTypes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := selectEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				log:            c.GetLog(r),
+				encoding:       encoding,
+				contentTypes:   contentTypes,
+			}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
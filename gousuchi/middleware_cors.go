@@ -0,0 +1,155 @@
+package gousuchi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures CORSMiddleware
+type CORSOptions struct {
+	// AllowedOrigins can contain exact origins (e.g. "https://example.com"), origins with
+	// a wildcard subdomain (e.g. "https://*.example.com") or "*" to allow all origins
+	//
+	// "*" and other wildcards than subdomains can't be used with AllowCredentials
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, POST, PUT, PATCH, DELETE and HEAD
+	AllowedMethods []string
+	// AllowedHeaders defaults to the headers requested in the preflight request
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is the duration preflight requests are cached by the client
+	MaxAge time.Duration
+}
+
+// validate checks that credentials are only allowed for explicitly allowed origins
+func (o *CORSOptions) validate() error {
+	if !o.AllowCredentials {
+		return nil
+	}
+
+	for _, allowedOrigin := range o.AllowedOrigins {
+		_, suffix, isWildcard := strings.Cut(allowedOrigin, "*")
+		if isWildcard && !strings.HasPrefix(suffix, ".") {
+			return fmt.Errorf("allowed origin '%s' can't be used with credentials", allowedOrigin)
+		}
+	}
+
+	return nil
+}
+
+func (o *CORSOptions) isOriginAllowed(origin string) bool {
+	for _, allowedOrigin := range o.AllowedOrigins {
+		if allowedOrigin == origin {
+			return true
+		}
+
+		// Credentials are only allowed for explicitly allowed origins
+		if allowedOrigin == "*" && !o.AllowCredentials {
+			return true
+		}
+
+		prefix, suffix, isWildcard := strings.Cut(allowedOrigin, "*")
+		if isWildcard &&
+			len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) &&
+			strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CORSMiddleware adds CORS headers for allowed origins and answers preflight requests
+//
+// Requests from other origins are passed on without CORS headers, so browsers block them.
+//
+// Panics if AllowCredentials is used with the origin "*" (or another wildcard than
+// subdomains), as any website could make credentialed requests then
+func (c *AbstractController) CORSMiddleware(options CORSOptions) Middleware {
+	err := options.validate()
+	if err != nil {
+		panic(fmt.Sprintf("invalid CORS options: %s", err))
+	}
+
+	allowedMethods := options.AllowedMethods
+	if len(allowedMethods) == 0 {
+		allowedMethods = []string{
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+			http.MethodHead,
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			isPreflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			w.Header().Add("Vary", "Origin")
+
+			if origin == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if !options.isOriginAllowed(origin) {
+				c.GetLog(r).Debugf("CORS request from origin %s not allowed", c.sanitizeHeaderString(origin, 128))
+
+				if isPreflight {
+					w.WriteHeader(http.StatusNoContent)
+
+					return
+				}
+
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			// Credentials can't be used with the origin "*"
+			if options.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			} else if len(options.AllowedOrigins) == 1 && options.AllowedOrigins[0] == "*" {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+
+			if !isPreflight {
+				if len(options.ExposedHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(options.ExposedHeaders, ", "))
+				}
+
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ", "))
+
+			if len(options.AllowedHeaders) > 0 {
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(options.AllowedHeaders, ", "))
+			} else if requestedHeaders := r.Header.Get("Access-Control-Request-Headers"); requestedHeaders != "" {
+				w.Header().Set("Access-Control-Allow-Headers", requestedHeaders)
+			}
+
+			if options.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(options.MaxAge.Seconds())))
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package gousuchi
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SecurityHeadersOptions configures SecurityHeadersMiddleware, empty values omit the header
+type SecurityHeadersOptions struct {
+	ContentSecurityPolicy string
+	FrameOptions          string
+	ReferrerPolicy        string
+	PermissionsPolicy     string
+	// HSTSMaxAge enables the Strict-Transport-Security header for https requests
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+}
+
+// DefaultSecurityHeadersOptions returns restrictive security headers suitable for APIs
+func DefaultSecurityHeadersOptions() SecurityHeadersOptions {
+	return SecurityHeadersOptions{
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+	}
+}
+
+// SecurityHeadersMiddleware sets security related response headers
+//
+// X-Content-Type-Options is always set to nosniff. Strict-Transport-Security is
+// only set for requests via https (directly or via X-Forwarded-Proto)
+func (c *AbstractController) SecurityHeadersMiddleware(options SecurityHeadersOptions) Middleware {
+	hsts := ""
+	if options.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", int(options.HSTSMaxAge.Seconds()))
+
+		if options.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	headers := map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": options.ContentSecurityPolicy,
+		"X-Frame-Options":         options.FrameOptions,
+		"Referrer-Policy":         options.ReferrerPolicy,
+		"Permissions-Policy":      options.PermissionsPolicy,
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for key, value := range headers {
+				if value != "" {
+					w.Header().Set(key, value)
+				}
+			}
+
+			if hsts != "" && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+				w.Header().Set("Strict-Transport-Security", hsts)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
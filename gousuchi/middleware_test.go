@@ -0,0 +1,213 @@
+package gousuchi
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	requestID := ""
+
+	handler := c.RequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = GetRequestID(r)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(HeaderRequestID, "abc-123")
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	assert.Equal(t, "abc-123", requestID)
+	assert.Equal(t, "abc-123", writer.Header().Get(HeaderRequestID))
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(HeaderRequestID, "invalid\nid")
+
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	assert.Len(t, requestID, 32)
+	assert.Equal(t, requestID, writer.Header().Get(HeaderRequestID))
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	handler := c.RecoverMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("test")
+	}))
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, writer.Code)
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	handler := c.BodyLimitMiddleware(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := struct {
+			Name string `json:"name"`
+		}{}
+
+		c.Wrap(func(w http.ResponseWriter, r *http.Request) IResponse {
+			resp := Bind(r, &target)
+			if resp != nil {
+				return resp
+			}
+
+			return Text(r, "OK")
+		})(w, r)
+	}))
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"test"}`)))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, writer.Code)
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"test"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, writer.Code)
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	handler := c.TimeoutMiddleware(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, writer.Code)
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	body := strings.Repeat("compressible ", 100)
+
+	handler := c.CompressMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, body)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	assert.Equal(t, "br", writer.Header().Get("Content-Encoding"))
+
+	decoded, err := io.ReadAll(brotli.NewReader(writer.Body))
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decoded))
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip;q=0.8, br;q=0")
+
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	assert.Equal(t, "gzip", writer.Header().Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(writer.Body)
+	require.NoError(t, err)
+
+	decoded, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decoded))
+
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, "", writer.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, writer.Body.String())
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	handler := c.CORSMiddleware(CORSOptions{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("OPTIONS", "/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Authorization")
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusNoContent, writer.Code)
+	assert.Equal(t, "https://app.example.com", writer.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", writer.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Authorization", writer.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "3600", writer.Header().Get("Access-Control-Max-Age"))
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://example.org")
+
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "", writer.Header().Get("Access-Control-Allow-Origin"))
+
+	// Credentials can't be allowed for all origins
+	assert.Panics(t, func() {
+		c.CORSMiddleware(CORSOptions{
+			AllowedOrigins:   []string{"*"},
+			AllowCredentials: true,
+		})
+	})
+
+	assert.Panics(t, func() {
+		c.CORSMiddleware(CORSOptions{
+			AllowedOrigins:   []string{"https://*"},
+			AllowCredentials: true,
+		})
+	})
+}
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	handler := c.SecurityHeadersMiddleware(DefaultSecurityHeadersOptions())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	assert.Equal(t, "nosniff", writer.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", writer.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", writer.Header().Get("Strict-Transport-Security"))
+}
@@ -0,0 +1,755 @@
+package gousuchi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIVersion is the version of the generated OpenAPI documents
+const OpenAPIVersion = "3.1.0"
+
+// MaxValidatedBodySize is the maximum size of request bodies validated via RouteDoc.WithValidation
+const MaxValidatedBodySize = 10 << 20
+
+// ParamType is the type of a documented parameter, matching the QueryParam*/URLParam* helpers
+type ParamType string
+
+const (
+	ParamTypeString      ParamType = "string"
+	ParamTypeInt64       ParamType = "int64"
+	ParamTypeBool        ParamType = "bool"
+	ParamTypeFloat64     ParamType = "float64"
+	ParamTypeUUID        ParamType = "uuid"
+	ParamTypeTime        ParamType = "time"
+	ParamTypeDate        ParamType = "date"
+	ParamTypeDuration    ParamType = "duration"
+	ParamTypeEnum        ParamType = "enum"
+	ParamTypeStringSlice ParamType = "[]string"
+	ParamTypeInt64Slice  ParamType = "[]int64"
+)
+
+// schema returns the OpenAPI schema of the parameter type
+func (t ParamType) schema(allowedValues []string) *OpenAPISchema {
+	switch t {
+	case ParamTypeInt64:
+		return &OpenAPISchema{Type: SchemaTypeInteger, Format: "int64"}
+	case ParamTypeBool:
+		return &OpenAPISchema{Type: SchemaTypeBoolean}
+	case ParamTypeFloat64:
+		return &OpenAPISchema{Type: SchemaTypeNumber, Format: "double"}
+	case ParamTypeUUID:
+		return &OpenAPISchema{Type: SchemaTypeString, Format: "uuid"}
+	case ParamTypeTime:
+		return &OpenAPISchema{Type: SchemaTypeString, Format: "date-time"}
+	case ParamTypeDate:
+		return &OpenAPISchema{Type: SchemaTypeString, Format: "date"}
+	case ParamTypeDuration:
+		return &OpenAPISchema{Type: SchemaTypeString, Format: "duration"}
+	case ParamTypeEnum:
+		schema := &OpenAPISchema{Type: SchemaTypeString}
+		for _, allowedValue := range allowedValues {
+			schema.Enum = append(schema.Enum, allowedValue)
+		}
+
+		return schema
+	case ParamTypeStringSlice:
+		return &OpenAPISchema{Type: SchemaTypeArray, Items: &OpenAPISchema{Type: SchemaTypeString}}
+	case ParamTypeInt64Slice:
+		return &OpenAPISchema{Type: SchemaTypeArray, Items: &OpenAPISchema{Type: SchemaTypeInteger, Format: "int64"}}
+	default:
+		return &OpenAPISchema{Type: SchemaTypeString}
+	}
+}
+
+// parseValue parses a single value of the parameter type
+func (t ParamType) parseValue(valueStr string, allowedValues []string) (interface{}, error) {
+	switch t {
+	case ParamTypeInt64, ParamTypeInt64Slice:
+		return strconv.ParseInt(valueStr, 10, 64)
+	case ParamTypeBool:
+		return strconv.ParseBool(valueStr)
+	case ParamTypeFloat64:
+		return parseFloat64(valueStr)
+	case ParamTypeUUID:
+		return uuid.Parse(valueStr)
+	case ParamTypeTime:
+		return parseTime(valueStr)
+	case ParamTypeDate:
+		return parseDate(valueStr)
+	case ParamTypeDuration:
+		return time.ParseDuration(valueStr)
+	case ParamTypeEnum:
+		return parseEnum(allowedValues)(valueStr)
+	default:
+		return valueStr, nil
+	}
+}
+
+// parse checks if a single value is valid for the parameter type
+func (t ParamType) parse(valueStr string, allowedValues []string) error {
+	_, err := t.parseValue(valueStr, allowedValues)
+
+	return err
+}
+
+// ParamDoc documents a query or url parameter of a route
+type ParamDoc struct {
+	Name string
+	// In is either FieldSourceQuery or FieldSourceURL
+	In            string
+	Type          ParamType
+	Required      bool
+	Description   string
+	AllowedValues []string
+}
+
+type responseDoc struct {
+	description string
+	contentType ContentType
+	sample      interface{}
+}
+
+// RouteDoc documents a route registered via AbstractController.Handle
+type RouteDoc struct {
+	method      string
+	operationID string
+	summary     string
+	description string
+	tags        []string
+	deprecated  bool
+	params      []*ParamDoc
+	requestBody interface{}
+	responses   map[int]*responseDoc
+	validate    bool
+}
+
+// WithOperationID sets the unique id of the operation (e.g. "getUser")
+func (d *RouteDoc) WithOperationID(operationID string) *RouteDoc {
+	d.operationID = operationID
+
+	return d
+}
+
+// WithSummary sets a short summary of the route
+func (d *RouteDoc) WithSummary(summary string) *RouteDoc {
+	d.summary = summary
+
+	return d
+}
+
+// WithDescription sets a detailed description of the route
+func (d *RouteDoc) WithDescription(description string) *RouteDoc {
+	d.description = description
+
+	return d
+}
+
+// WithTags adds tags to group the route in the document
+func (d *RouteDoc) WithTags(tags ...string) *RouteDoc {
+	d.tags = append(d.tags, tags...)
+
+	return d
+}
+
+// Deprecated marks the route as deprecated
+func (d *RouteDoc) Deprecated() *RouteDoc {
+	d.deprecated = true
+
+	return d
+}
+
+// WithQueryParam documents a parameter of the url's query
+func (d *RouteDoc) WithQueryParam(name string, paramType ParamType, required bool, description string) *RouteDoc {
+	d.params = append(d.params, &ParamDoc{
+		Name:        name,
+		In:          FieldSourceQuery,
+		Type:        paramType,
+		Required:    required,
+		Description: description,
+	})
+
+	return d
+}
+
+// WithQueryParamEnum documents a parameter of the url's query accepting only the allowed values
+func (d *RouteDoc) WithQueryParamEnum(name string, allowedValues []string, required bool, description string) *RouteDoc {
+	d.params = append(d.params, &ParamDoc{
+		Name:          name,
+		In:            FieldSourceQuery,
+		Type:          ParamTypeEnum,
+		Required:      required,
+		Description:   description,
+		AllowedValues: allowedValues,
+	})
+
+	return d
+}
+
+// WithURLParam documents a chi url parameter
+func (d *RouteDoc) WithURLParam(name string, paramType ParamType, description string) *RouteDoc {
+	d.params = append(d.params, &ParamDoc{
+		Name:        name,
+		In:          FieldSourceURL,
+		Type:        paramType,
+		Required:    true,
+		Description: description,
+	})
+
+	return d
+}
+
+// WithURLParamEnum documents a chi url parameter accepting only the allowed values
+func (d *RouteDoc) WithURLParamEnum(name string, allowedValues []string, description string) *RouteDoc {
+	d.params = append(d.params, &ParamDoc{
+		Name:          name,
+		In:            FieldSourceURL,
+		Type:          ParamTypeEnum,
+		Required:      true,
+		Description:   description,
+		AllowedValues: allowedValues,
+	})
+
+	return d
+}
+
+// WithRequestBody documents the json request body, the schema is generated from the
+// sample's type (e.g. &CreateUserRequest{}) including the rules of its validate tags
+func (d *RouteDoc) WithRequestBody(sample interface{}) *RouteDoc {
+	d.requestBody = sample
+
+	return d
+}
+
+// WithResponse documents a json response, the schema is generated from the sample's
+// type, a nil sample documents a response without body
+func (d *RouteDoc) WithResponse(statusCode int, sample interface{}, description string) *RouteDoc {
+	contentType := ContentTypeApplicationJSON
+	if sample == nil {
+		contentType = ""
+	}
+
+	d.responses[statusCode] = &responseDoc{
+		description: description,
+		contentType: contentType,
+		sample:      sample,
+	}
+
+	return d
+}
+
+// WithResponseContent documents a response with a non-json body (e.g. ContentTypeImagePNG)
+func (d *RouteDoc) WithResponseContent(statusCode int, contentType ContentType, description string) *RouteDoc {
+	d.responses[statusCode] = &responseDoc{
+		description: description,
+		contentType: contentType,
+	}
+
+	return d
+}
+
+// WithErrors documents error responses with problem details (see ResponseError)
+func (d *RouteDoc) WithErrors(statusCodes ...int) *RouteDoc {
+	for _, statusCode := range statusCodes {
+		d.responses[statusCode] = &responseDoc{
+			description: http.StatusText(statusCode),
+			contentType: ContentTypeApplicationProblemJSON,
+		}
+	}
+
+	return d
+}
+
+// WithValidation enables the validation of requests against the documentation
+//
+// The documented parameters and the json request body are checked before the handler
+// is called, invalid requests are rejected with a BadRequest-Response listing all
+// field errors. The request body is validated with the rules of Bind and must not
+// exceed MaxValidatedBodySize.
+func (d *RouteDoc) WithValidation() *RouteDoc {
+	d.validate = true
+
+	return d
+}
+
+// validateRequestBody decodes a json body into a new value of the sample's type and
+// validates it like Bind
+func validateRequestBody(body []byte, sample interface{}) ([]*FieldError, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	bodyType := reflect.TypeOf(sample)
+	for bodyType.Kind() == reflect.Pointer {
+		bodyType = bodyType.Elem()
+	}
+
+	target := reflect.New(bodyType).Interface()
+
+	fieldErrors, err := decodeJSONBody(bytes.NewReader(body), target)
+	if err != nil || len(fieldErrors) > 0 {
+		return fieldErrors, err
+	}
+
+	if bodyType.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	validationErrors, err := ValidateStruct(target)
+	if err != nil {
+		return nil, fmt.Errorf("can't validate request body: %s", err)
+	}
+
+	return validationErrors, nil
+}
+
+// validateRequest validates the request against the documented parameters and request body
+func (d *RouteDoc) validateRequest(r *http.Request) IResponse {
+	fieldErrors := []*FieldError{}
+
+	query := r.URL.Query()
+
+	for _, param := range d.params {
+		var values []string
+
+		switch param.In {
+		case FieldSourceQuery:
+			values = query[param.Name]
+		case FieldSourceURL:
+			value := chi.URLParam(r, param.Name)
+			if value != "" {
+				values = []string{value}
+			}
+		}
+
+		if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+			if param.Required {
+				fieldErrors = append(fieldErrors, &FieldError{
+					Field:   param.Name,
+					Source:  param.In,
+					Rule:    ValidationRuleRequired,
+					Message: "is required",
+				})
+			}
+
+			continue
+		}
+
+		if param.Type != ParamTypeStringSlice && param.Type != ParamTypeInt64Slice {
+			values = values[:1]
+		}
+
+		for _, value := range values {
+			err := param.Type.parse(value, param.AllowedValues)
+			if err != nil {
+				rule := ValidationRuleType
+				if param.Type == ParamTypeEnum {
+					rule = ValidationRuleEnum
+				}
+
+				fieldErrors = append(fieldErrors, &FieldError{
+					Field:   param.Name,
+					Source:  param.In,
+					Rule:    rule,
+					Message: fmt.Sprintf("invalid value '%s': %s", value, err),
+				})
+
+				break
+			}
+		}
+	}
+
+	if d.requestBody != nil && r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, MaxValidatedBodySize))
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+			if errors.As(err, &maxBytesError) {
+				return RequestEntityTooLarge(r, "Request body exceeds limit of %d bytes", maxBytesError.Limit)
+			}
+
+			return BadRequest(r, "Can't read request body: %s", err)
+		}
+
+		// The handler still has to decode the body
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		bodyErrors, err := validateRequestBody(body, d.requestBody)
+		if err != nil {
+			return BadRequest(r, "Invalid request body: %s", err)
+		}
+
+		fieldErrors = append(fieldErrors, bodyErrors...)
+	}
+
+	if len(fieldErrors) > 0 {
+		return BadRequest(r, "Invalid request: %s", joinFieldErrors(fieldErrors)).
+			WithFieldErrors(fieldErrors)
+	}
+
+	return nil
+}
+
+type documentedHandler struct {
+	doc     *RouteDoc
+	handler http.HandlerFunc
+}
+
+func (h *documentedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler(w, r)
+}
+
+// Handle registers a wrapped handler function (see Wrap) on the controller's router and
+// returns its documentation for the OpenAPI document (see ServeOpenAPI)
+//
+// e.g. c.Handle(http.MethodGet, "/users/{userID}", c.getUser).
+//
+//	WithURLParam("userID", gousuchi.ParamTypeInt64, "ID of the user").
+//	WithResponse(http.StatusOK, &User{}, "The user").
+//	WithErrors(http.StatusNotFound)
+func (c *AbstractController) Handle(method string, pattern string, clb HandlerFunction) *RouteDoc {
+	doc := &RouteDoc{
+		method:    strings.ToUpper(method),
+		responses: map[int]*responseDoc{},
+	}
+
+	handler := c.Wrap(func(w http.ResponseWriter, r *http.Request) IResponse {
+		if doc.validate {
+			resp := doc.validateRequest(r)
+			if resp != nil {
+				return resp
+			}
+		}
+
+		return clb(w, r)
+	})
+
+	c.router.Method(doc.method, pattern, &documentedHandler{
+		doc:     doc,
+		handler: handler,
+	})
+
+	return doc
+}
+
+// OpenAPIInfo is the metadata of an OpenAPI document
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIMediaType is the content of a request or response body
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema,omitempty"`
+}
+
+// OpenAPIParameter is a parameter of an operation
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIRequestBody is the request body of an operation
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response of an operation
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIOperation is a route in an OpenAPI document
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses,omitempty"`
+}
+
+// OpenAPIComponents contains the named schemas of an OpenAPI document
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+// OpenAPIDocument is an OpenAPI 3.1 document
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components *OpenAPIComponents                      `json:"components,omitempty"`
+}
+
+// YAML returns the document encoded as yaml
+func (d *OpenAPIDocument) YAML() ([]byte, error) {
+	// Encoding via json applies the json tags and omits empty fields
+	jsonData, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+
+	err = json.Unmarshal(jsonData, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(value)
+}
+
+var regexpPatternParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// problemSchemaName is the name of the schema of error responses (see ResponseError.Problem)
+const problemSchemaName = "Problem"
+
+func problemSchema() *OpenAPISchema {
+	return &OpenAPISchema{
+		Type: SchemaTypeObject,
+		Properties: map[string]*OpenAPISchema{
+			"type":       {Type: SchemaTypeString},
+			"title":      {Type: SchemaTypeString},
+			"status":     {Type: SchemaTypeInteger},
+			"detail":     {Type: SchemaTypeString},
+			"instance":   {Type: SchemaTypeString},
+			"request_id": {Type: SchemaTypeString},
+			"errors": {
+				Type: SchemaTypeArray,
+				Items: &OpenAPISchema{
+					Type: SchemaTypeObject,
+					Properties: map[string]*OpenAPISchema{
+						"field":   {Type: SchemaTypeString},
+						"source":  {Type: SchemaTypeString},
+						"rule":    {Type: SchemaTypeString},
+						"message": {Type: SchemaTypeString},
+					},
+					Required: []string{"field", "rule", "message"},
+				},
+			},
+		},
+		Required: []string{"type", "title", "status"},
+	}
+}
+
+func (d *RouteDoc) operation(generator *schemaGenerator, pathParams []string) *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		OperationID: d.operationID,
+		Summary:     d.summary,
+		Description: d.description,
+		Tags:        d.tags,
+		Deprecated:  d.deprecated,
+		Parameters:  []*OpenAPIParameter{},
+	}
+
+	documentedParams := map[string]bool{}
+
+	for _, param := range d.params {
+		in := param.In
+		if in == FieldSourceURL {
+			in = "path"
+			documentedParams[param.Name] = true
+		}
+
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+			Name:        param.Name,
+			In:          in,
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      param.Type.schema(param.AllowedValues),
+		})
+	}
+
+	for _, name := range pathParams {
+		if !documentedParams[name] {
+			operation.Parameters = append(operation.Parameters, undocumentedPathParam(name))
+		}
+	}
+
+	if d.requestBody != nil {
+		operation.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content: map[string]*OpenAPIMediaType{
+				string(ContentTypeApplicationJSON): {
+					Schema: generator.schema(reflect.TypeOf(d.requestBody)),
+				},
+			},
+		}
+	}
+
+	if len(d.responses) > 0 {
+		operation.Responses = map[string]*OpenAPIResponse{}
+	}
+
+	for statusCode, response := range d.responses {
+		openAPIResponse := &OpenAPIResponse{
+			Description: response.description,
+		}
+
+		if openAPIResponse.Description == "" {
+			openAPIResponse.Description = http.StatusText(statusCode)
+		}
+
+		switch {
+		case response.contentType == ContentTypeApplicationProblemJSON:
+			generator.components[problemSchemaName] = problemSchema()
+
+			openAPIResponse.Content = map[string]*OpenAPIMediaType{
+				string(response.contentType): {
+					Schema: &OpenAPISchema{Ref: "#/components/schemas/" + problemSchemaName},
+				},
+			}
+		case response.sample != nil:
+			openAPIResponse.Content = map[string]*OpenAPIMediaType{
+				string(response.contentType): {
+					Schema: generator.schema(reflect.TypeOf(response.sample)),
+				},
+			}
+		case response.contentType != "":
+			openAPIResponse.Content = map[string]*OpenAPIMediaType{
+				string(response.contentType): {
+					Schema: &OpenAPISchema{Type: SchemaTypeString, Format: "binary"},
+				},
+			}
+		}
+
+		operation.Responses[strconv.Itoa(statusCode)] = openAPIResponse
+	}
+
+	return operation
+}
+
+func undocumentedPathParam(name string) *OpenAPIParameter {
+	return &OpenAPIParameter{
+		Name:     name,
+		In:       "path",
+		Required: true,
+		Schema:   &OpenAPISchema{Type: SchemaTypeString},
+	}
+}
+
+var openAPIMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPut:     true,
+	http.MethodPost:    true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	http.MethodHead:    true,
+	http.MethodPatch:   true,
+	http.MethodTrace:   true,
+}
+
+// OpenAPI generates an OpenAPI 3.1 document of all routes of the controller's router
+//
+// Routes registered via Handle include their documentation, all other routes are
+// listed without details.
+func (c *AbstractController) OpenAPI(info OpenAPIInfo) (*OpenAPIDocument, error) {
+	document := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   map[string]map[string]*OpenAPIOperation{},
+	}
+
+	generator := newSchemaGenerator()
+
+	err := chi.Walk(c.router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if !openAPIMethods[method] {
+			return nil
+		}
+
+		pathParams := []string{}
+		for _, match := range regexpPatternParam.FindAllStringSubmatch(route, -1) {
+			pathParams = append(pathParams, match[1])
+		}
+
+		path := regexpPatternParam.ReplaceAllString(route, "{$1}")
+
+		var operation *OpenAPIOperation
+
+		documented, ok := handler.(*documentedHandler)
+		if ok {
+			operation = documented.doc.operation(generator, pathParams)
+		} else {
+			operation = &OpenAPIOperation{}
+
+			for _, name := range pathParams {
+				operation.Parameters = append(operation.Parameters, undocumentedPathParam(name))
+			}
+		}
+
+		if _, exists := document.Paths[path]; !exists {
+			document.Paths[path] = map[string]*OpenAPIOperation{}
+		}
+
+		document.Paths[path][strings.ToLower(method)] = operation
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't walk routes: %w", err)
+	}
+
+	if len(generator.components) > 0 {
+		document.Components = &OpenAPIComponents{
+			Schemas: generator.components,
+		}
+	}
+
+	return document, nil
+}
+
+// ServeOpenAPI registers a route serving the OpenAPI document of the controller's router
+//
+// The document is encoded as yaml if the pattern ends with .yaml or .yml, else as json.
+// It is generated on each request, so routes registered later are included.
+func (c *AbstractController) ServeOpenAPI(pattern string, info OpenAPIInfo) *RouteDoc {
+	isYAML := strings.HasSuffix(pattern, ".yaml") || strings.HasSuffix(pattern, ".yml")
+
+	contentType := ContentTypeApplicationJSON
+	if isYAML {
+		contentType = "application/yaml"
+	}
+
+	return c.Handle(http.MethodGet, pattern, func(w http.ResponseWriter, r *http.Request) IResponse {
+		document, err := c.OpenAPI(info)
+		if err != nil {
+			return InternalServerError(r, "Can't generate OpenAPI document: %s", err)
+		}
+
+		var body []byte
+
+		if isYAML {
+			body, err = document.YAML()
+		} else {
+			body, err = json.Marshal(document)
+		}
+		if err != nil {
+			return InternalServerError(r, "Can't encode OpenAPI document: %s", err)
+		}
+
+		return NewResponse(r, http.StatusOK, contentType, body).
+			WithoutLogging()
+	}).
+		WithSummary("OpenAPI document").
+		WithResponseContent(http.StatusOK, contentType, "The OpenAPI document")
+}
@@ -0,0 +1,295 @@
+package gousuchi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/guregu/null.v4"
+)
+
+// OpenAPI schema types
+const (
+	SchemaTypeString  = "string"
+	SchemaTypeInteger = "integer"
+	SchemaTypeNumber  = "number"
+	SchemaTypeBoolean = "boolean"
+	SchemaTypeArray   = "array"
+	SchemaTypeObject  = "object"
+	SchemaTypeNull    = "null"
+)
+
+// OpenAPISchema is a (reduced) JSON schema as used by OpenAPI 3.1
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 interface{}               `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Enum                 []interface{}             `json:"enum,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty"`
+}
+
+// types returns the allowed types of the schema
+func (s *OpenAPISchema) types() []string {
+	switch schemaType := s.Type.(type) {
+	case string:
+		return []string{schemaType}
+	case []string:
+		return schemaType
+	default:
+		return nil
+	}
+}
+
+var (
+	typeNullString = reflect.TypeOf(null.String{})
+	typeNullInt    = reflect.TypeOf(null.Int{})
+	typeNullFloat  = reflect.TypeOf(null.Float{})
+	typeNullBool   = reflect.TypeOf(null.Bool{})
+	typeNullTime   = reflect.TypeOf(null.Time{})
+	typeUUID       = reflect.TypeOf(uuid.UUID{})
+	typeDuration   = reflect.TypeOf(time.Duration(0))
+	typeRawMessage = reflect.TypeOf(json.RawMessage{})
+)
+
+var regexpSchemaName = regexp.MustCompile(`[^a-zA-Z0-9._\-]+`)
+
+// schemaGenerator creates schemas from go types, named structs are added as components
+type schemaGenerator struct {
+	components map[string]*OpenAPISchema
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		components: map[string]*OpenAPISchema{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	name, ok := g.names[t]
+	if ok {
+		return name
+	}
+
+	name = regexpSchemaName.ReplaceAllString(t.Name(), "_")
+
+	if _, exists := g.components[name]; exists {
+		pkgPath := strings.Split(t.PkgPath(), "/")
+		name = regexpSchemaName.ReplaceAllString(pkgPath[len(pkgPath)-1]+"."+t.Name(), "_")
+	}
+
+	g.names[t] = name
+
+	return name
+}
+
+func nullable(schemaType string) []string {
+	return []string{schemaType, SchemaTypeNull}
+}
+
+// schema returns the schema for a go type
+func (g *schemaGenerator) schema(t reflect.Type) *OpenAPISchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case typeTime:
+		return &OpenAPISchema{Type: SchemaTypeString, Format: "date-time"}
+	case typeNullTime:
+		return &OpenAPISchema{Type: nullable(SchemaTypeString), Format: "date-time"}
+	case typeNullString:
+		return &OpenAPISchema{Type: nullable(SchemaTypeString)}
+	case typeNullInt:
+		return &OpenAPISchema{Type: nullable(SchemaTypeInteger), Format: "int64"}
+	case typeNullFloat:
+		return &OpenAPISchema{Type: nullable(SchemaTypeNumber), Format: "double"}
+	case typeNullBool:
+		return &OpenAPISchema{Type: nullable(SchemaTypeBoolean)}
+	case typeUUID:
+		return &OpenAPISchema{Type: SchemaTypeString, Format: "uuid"}
+	case typeRawMessage:
+		return &OpenAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &OpenAPISchema{Type: SchemaTypeString}
+	case reflect.Bool:
+		return &OpenAPISchema{Type: SchemaTypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: SchemaTypeInteger, Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: SchemaTypeInteger, Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: SchemaTypeNumber, Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: SchemaTypeNumber, Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: SchemaTypeString, Format: "byte"}
+		}
+
+		return &OpenAPISchema{Type: SchemaTypeArray, Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: SchemaTypeObject, AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		name := g.componentName(t)
+		if _, exists := g.components[name]; !exists {
+			// Register before generating, so recursive types terminate
+			g.components[name] = &OpenAPISchema{}
+			*g.components[name] = *g.structSchema(t)
+		}
+
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	default:
+		return &OpenAPISchema{}
+	}
+}
+
+func (g *schemaGenerator) addStructFields(t reflect.Type, schema *OpenAPISchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		// Embedded structs without json name are flattened
+		if field.Anonymous && jsonName == "" {
+			embeddedType := field.Type
+			for embeddedType.Kind() == reflect.Pointer {
+				embeddedType = embeddedType.Elem()
+			}
+
+			if embeddedType.Kind() == reflect.Struct {
+				g.addStructFields(embeddedType, schema)
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		// Fields bound from other sources are not part of the body
+		if jsonName == "" && (field.Tag.Get(FieldSourceQuery) != "" || field.Tag.Get(FieldSourceURL) != "" || field.Tag.Get(FieldSourceForm) != "") {
+			continue
+		}
+
+		name := jsonName
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := g.schema(field.Type)
+
+		required := applyValidationTag(fieldSchema, field.Tag.Get("validate"))
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+
+		schema.Properties[name] = fieldSchema
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{
+		Type:       SchemaTypeObject,
+		Properties: map[string]*OpenAPISchema{},
+	}
+
+	g.addStructFields(t, schema)
+
+	return schema
+}
+
+// applyValidationTag adds the rules of a validate tag to a schema and returns
+// if the field is required
+func applyValidationTag(schema *OpenAPISchema, tag string) bool {
+	required := false
+
+	if schema.Ref != "" {
+		return strings.Contains(","+tag+",", ","+ValidationRuleRequired+",")
+	}
+
+	types := schema.types()
+	isNumber := len(types) > 0 && (types[0] == SchemaTypeInteger || types[0] == SchemaTypeNumber)
+	isArray := len(types) > 0 && types[0] == SchemaTypeArray
+
+	for _, rule := range splitRules(tag) {
+		rule, param, _ := strings.Cut(rule, "=")
+
+		switch rule {
+		case ValidationRuleRequired:
+			required = true
+		case ValidationRuleMin, ValidationRuleMax, ValidationRuleLen:
+			limit, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+
+			intLimit := int(limit)
+
+			switch {
+			case isNumber && rule == ValidationRuleMin:
+				schema.Minimum = &limit
+			case isNumber && rule == ValidationRuleMax:
+				schema.Maximum = &limit
+			case isArray && rule != ValidationRuleMax:
+				schema.MinItems = &intLimit
+				if rule == ValidationRuleLen {
+					schema.MaxItems = &intLimit
+				}
+			case isArray:
+				schema.MaxItems = &intLimit
+			case rule != ValidationRuleMax:
+				schema.MinLength = &intLimit
+				if rule == ValidationRuleLen {
+					schema.MaxLength = &intLimit
+				}
+			default:
+				schema.MaxLength = &intLimit
+			}
+		case ValidationRuleEnum:
+			for _, value := range strings.Split(param, "|") {
+				if isNumber {
+					number, err := strconv.ParseFloat(value, 64)
+					if err == nil {
+						schema.Enum = append(schema.Enum, number)
+
+						continue
+					}
+				}
+
+				schema.Enum = append(schema.Enum, value)
+			}
+		case ValidationRuleRegex:
+			schema.Pattern = param
+		}
+	}
+
+	return required
+}
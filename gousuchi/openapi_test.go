@@ -0,0 +1,192 @@
+package gousuchi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+	"gopkg.in/yaml.v3"
+)
+
+type testOpenAPIAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type testOpenAPIUser struct {
+	ID       int64               `json:"id"`
+	Name     string              `json:"name" validate:"required,min=2,max=10"`
+	Role     string              `json:"role" validate:"enum=admin|user"`
+	Email    null.String         `json:"email"`
+	Tags     []string            `json:"tags" validate:"max=3"`
+	Address  *testOpenAPIAddress `json:"address"`
+	Limit    int                 `query:"limit"`
+	Internal string              `json:"-"`
+}
+
+func newTestOpenAPIController() *AbstractController {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	c.Handle(http.MethodGet, "/users/{userID:[0-9]+}", func(w http.ResponseWriter, r *http.Request) IResponse {
+		return JSON(r, &testOpenAPIUser{})
+	}).
+		WithOperationID("getUser").
+		WithSummary("Get a user").
+		WithTags("users").
+		WithURLParam("userID", ParamTypeInt64, "ID of the user").
+		WithQueryParamEnum("expand", []string{"address"}, false, "Expand relations").
+		WithResponse(http.StatusOK, &testOpenAPIUser{}, "The user").
+		WithErrors(http.StatusNotFound)
+
+	c.Handle(http.MethodPost, "/users", func(w http.ResponseWriter, r *http.Request) IResponse {
+		return NewResponse(r, http.StatusCreated, ContentTypeTextPlain, []byte("created"))
+	}).
+		WithRequestBody(&testOpenAPIUser{}).
+		WithQueryParam("dryRun", ParamTypeBool, false, "").
+		WithResponse(http.StatusCreated, nil, "").
+		WithErrors(http.StatusBadRequest).
+		WithValidation()
+
+	c.router.Get("/health/{check}", func(w http.ResponseWriter, r *http.Request) {})
+
+	return c
+}
+
+func TestOpenAPI(t *testing.T) {
+	c := newTestOpenAPIController()
+
+	document, err := c.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.0.0"})
+	require.NoError(t, err)
+
+	assert.Equal(t, OpenAPIVersion, document.OpenAPI)
+	assert.Equal(t, "Test", document.Info.Title)
+
+	getUser := document.Paths["/users/{userID}"]["get"]
+	require.NotNil(t, getUser)
+	assert.Equal(t, "getUser", getUser.OperationID)
+	assert.Equal(t, []string{"users"}, getUser.Tags)
+	require.Len(t, getUser.Parameters, 2)
+	assert.Equal(t, "path", getUser.Parameters[0].In)
+	assert.True(t, getUser.Parameters[0].Required)
+	assert.Equal(t, SchemaTypeInteger, getUser.Parameters[0].Schema.Type)
+	assert.Equal(t, "query", getUser.Parameters[1].In)
+	assert.Equal(t, []interface{}{"address"}, getUser.Parameters[1].Schema.Enum)
+	assert.Equal(t, "#/components/schemas/testOpenAPIUser", getUser.Responses["200"].Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/Problem", getUser.Responses["404"].Content["application/problem+json"].Schema.Ref)
+
+	createUser := document.Paths["/users"]["post"]
+	require.NotNil(t, createUser)
+	require.NotNil(t, createUser.RequestBody)
+	assert.Equal(t, "Created", createUser.Responses["201"].Description)
+	assert.Nil(t, createUser.Responses["201"].Content)
+
+	health := document.Paths["/health/{check}"]["get"]
+	require.NotNil(t, health)
+	require.Len(t, health.Parameters, 1)
+	assert.Equal(t, "check", health.Parameters[0].Name)
+
+	user := document.Components.Schemas["testOpenAPIUser"]
+	require.NotNil(t, user)
+	assert.Equal(t, []string{"name"}, user.Required)
+	assert.Equal(t, 2, *user.Properties["name"].MinLength)
+	assert.Equal(t, 10, *user.Properties["name"].MaxLength)
+	assert.Equal(t, []interface{}{"admin", "user"}, user.Properties["role"].Enum)
+	assert.Equal(t, []string{SchemaTypeString, SchemaTypeNull}, user.Properties["email"].Type)
+	assert.Equal(t, 3, *user.Properties["tags"].MaxItems)
+	assert.Equal(t, "#/components/schemas/testOpenAPIAddress", user.Properties["address"].Ref)
+	assert.NotContains(t, user.Properties, "Limit")
+	assert.NotContains(t, user.Properties, "Internal")
+	assert.Contains(t, document.Components.Schemas, "testOpenAPIAddress")
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	c := newTestOpenAPIController()
+	c.ServeOpenAPI("/openapi.json", OpenAPIInfo{Title: "Test", Version: "1.0.0"})
+	c.ServeOpenAPI("/openapi.yaml", OpenAPIInfo{Title: "Test", Version: "1.0.0"})
+
+	writer := httptest.NewRecorder()
+	c.router.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "application/json", writer.Header().Get("Content-Type"))
+
+	document := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(writer.Body.Bytes(), &document))
+	assert.Equal(t, OpenAPIVersion, document["openapi"])
+	assert.Contains(t, document["paths"], "/openapi.yaml")
+
+	writer = httptest.NewRecorder()
+	c.router.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+
+	require.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "application/yaml", writer.Header().Get("Content-Type"))
+
+	document = map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(writer.Body.Bytes(), &document))
+	assert.Equal(t, OpenAPIVersion, document["openapi"])
+	assert.Contains(t, document["paths"], "/users")
+}
+
+func TestHandleValidation(t *testing.T) {
+	c := newTestOpenAPIController()
+
+	writer := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/users?dryRun=true", strings.NewReader(`{"name":"Test","role":"admin","address":{"city":"Berlin"}}`))
+	c.router.ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusCreated, writer.Code)
+
+	writer = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/users?dryRun=maybe", strings.NewReader(`{"name":"T","role":"guest","tags":["a","b","c","d"],"email":null,"address":{}}`))
+	req.Header.Set("Accept", string(ContentTypeApplicationJSON))
+	c.router.ServeHTTP(writer, req)
+
+	require.Equal(t, http.StatusBadRequest, writer.Code)
+
+	problem := struct {
+		Errors []*FieldError `json:"errors"`
+	}{}
+	require.NoError(t, json.Unmarshal(writer.Body.Bytes(), &problem))
+
+	rules := map[string]string{}
+	for _, fieldError := range problem.Errors {
+		rules[fieldError.Source+":"+fieldError.Field] = fieldError.Rule
+	}
+
+	assert.Equal(t, map[string]string{
+		"query:dryRun":      ValidationRuleType,
+		"body:name":         ValidationRuleMin,
+		"body:role":         ValidationRuleEnum,
+		"body:tags":         ValidationRuleMax,
+		"body:address.city": ValidationRuleRequired,
+	}, rules)
+
+	writer = httptest.NewRecorder()
+	c.router.ServeHTTP(writer, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":`)))
+
+	assert.Equal(t, http.StatusBadRequest, writer.Code)
+
+	// Empty values fail like in Bind
+	problem.Errors = nil
+	writer = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"","role":"admin"}`))
+	req.Header.Set("Accept", string(ContentTypeApplicationJSON))
+	c.router.ServeHTTP(writer, req)
+
+	require.Equal(t, http.StatusBadRequest, writer.Code)
+	require.NoError(t, json.Unmarshal(writer.Body.Bytes(), &problem))
+	require.Len(t, problem.Errors, 1)
+	assert.Equal(t, "name", problem.Errors[0].Field)
+	assert.Equal(t, ValidationRuleRequired, problem.Errors[0].Rule)
+
+	writer = httptest.NewRecorder()
+	body := `{"name":"Test","role":"admin","id":` + strings.Repeat(" ", MaxValidatedBodySize) + `1}`
+	c.router.ServeHTTP(writer, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, writer.Code)
+}
@@ -0,0 +1,575 @@
+package gousuchi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// Query params of list endpoints
+const (
+	ParamLimit  = "limit"
+	ParamOffset = "offset"
+	ParamCursor = "cursor"
+	ParamSort   = "sort"
+	ParamFilter = "filter"
+)
+
+// Default limits of ParseOffsetPage and ParseCursorPage
+const (
+	DefaultPageLimit     = 20
+	DefaultPageMaxLimit  = 100
+	DefaultPageMaxOffset = math.MaxInt32
+)
+
+// PaginationOptions configures the limits of ParseOffsetPage and ParseCursorPage, zero
+// values use the defaults
+type PaginationOptions struct {
+	// DefaultLimit is used if the request contains no limit (defaults to DefaultPageLimit)
+	DefaultLimit int
+	// MaxLimit is the maximum accepted limit (defaults to DefaultPageMaxLimit)
+	MaxLimit int
+	// MaxOffset is the maximum accepted offset of ParseOffsetPage (defaults to
+	// DefaultPageMaxOffset)
+	MaxOffset int
+}
+
+func (o *PaginationOptions) withDefaults() *PaginationOptions {
+	options := PaginationOptions{}
+	if o != nil {
+		options = *o
+	}
+
+	if options.MaxLimit <= 0 {
+		options.MaxLimit = DefaultPageMaxLimit
+	}
+
+	if options.MaxOffset <= 0 {
+		options.MaxOffset = DefaultPageMaxOffset
+	}
+
+	if options.DefaultLimit <= 0 {
+		options.DefaultLimit = DefaultPageLimit
+	}
+
+	if options.DefaultLimit > options.MaxLimit {
+		options.DefaultLimit = options.MaxLimit
+	}
+
+	return &options
+}
+
+// parsePageInt parses an optional integer query param within [min, max] (max is
+// ignored if <= 0)
+func parsePageInt(query url.Values, name string, defaultValue int, min int, max int) (int, *FieldError) {
+	valueStr := query.Get(name)
+	if valueStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return 0, &FieldError{
+			Field:   name,
+			Source:  FieldSourceQuery,
+			Rule:    ValidationRuleType,
+			Message: fmt.Sprintf("invalid value '%s': expected integer", valueStr),
+		}
+	}
+
+	if value < min {
+		return 0, &FieldError{
+			Field:   name,
+			Source:  FieldSourceQuery,
+			Rule:    ValidationRuleMin,
+			Message: fmt.Sprintf("must be at least %d", min),
+		}
+	}
+
+	if max > 0 && value > max {
+		return 0, &FieldError{
+			Field:   name,
+			Source:  FieldSourceQuery,
+			Rule:    ValidationRuleMax,
+			Message: fmt.Sprintf("must be at most %d", max),
+		}
+	}
+
+	return value, nil
+}
+
+func invalidQueryParams(request *http.Request, fieldErrors []*FieldError) IResponse {
+	return BadRequest(request, "Invalid query params: %s", joinFieldErrors(fieldErrors)).
+		WithFieldErrors(fieldErrors)
+}
+
+// OffsetPage is a page requested via the limit and offset query params
+type OffsetPage struct {
+	Limit  int
+	Offset int
+}
+
+// ParseOffsetPage loads the limit and offset query params
+//
+// If the params are invalid or the limit or offset exceed the maximum a BadRequest-Response
+// is returned, else the response is nil
+func ParseOffsetPage(request *http.Request, options *PaginationOptions) (*OffsetPage, IResponse) {
+	options = options.withDefaults()
+	query := request.URL.Query()
+	fieldErrors := []*FieldError{}
+
+	limit, fieldError := parsePageInt(query, ParamLimit, options.DefaultLimit, 1, options.MaxLimit)
+	if fieldError != nil {
+		fieldErrors = append(fieldErrors, fieldError)
+	}
+
+	offset, fieldError := parsePageInt(query, ParamOffset, 0, 0, options.MaxOffset)
+	if fieldError != nil {
+		fieldErrors = append(fieldErrors, fieldError)
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, invalidQueryParams(request, fieldErrors)
+	}
+
+	return &OffsetPage{
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// CursorPage is a page requested via the limit and cursor query params
+type CursorPage struct {
+	Limit int
+	// Cursor is the opaque cursor sent by the client (empty for the first page)
+	Cursor string
+}
+
+// EncodeCursor encodes a value (e.g. the sort key of the last item) as opaque cursor
+func EncodeCursor(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("can't encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes a cursor created by EncodeCursor into the target
+func DecodeCursor(cursor string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("can't decode cursor: %w", err)
+	}
+
+	err = json.Unmarshal(data, target)
+	if err != nil {
+		return fmt.Errorf("can't decode cursor: %w", err)
+	}
+
+	return nil
+}
+
+// ParseCursorPage loads the limit and cursor query params, the cursor is decoded into
+// the target (see DecodeCursor) if the target is not nil and the cursor is not empty
+//
+// If the params are invalid or the limit exceeds the maximum a BadRequest-Response is
+// returned, else the response is nil
+func ParseCursorPage(request *http.Request, options *PaginationOptions, target interface{}) (*CursorPage, IResponse) {
+	options = options.withDefaults()
+	query := request.URL.Query()
+	fieldErrors := []*FieldError{}
+
+	limit, fieldError := parsePageInt(query, ParamLimit, options.DefaultLimit, 1, options.MaxLimit)
+	if fieldError != nil {
+		fieldErrors = append(fieldErrors, fieldError)
+	}
+
+	cursor := query.Get(ParamCursor)
+	if cursor != "" && target != nil {
+		err := DecodeCursor(cursor, target)
+		if err != nil {
+			fieldErrors = append(fieldErrors, &FieldError{
+				Field:   ParamCursor,
+				Source:  FieldSourceQuery,
+				Rule:    ValidationRuleType,
+				Message: "invalid cursor",
+			})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, invalidQueryParams(request, fieldErrors)
+	}
+
+	return &CursorPage{
+		Limit:  limit,
+		Cursor: cursor,
+	}, nil
+}
+
+// SortField is a field to sort by
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// String returns the field in the format of the sort query param (e.g. -created)
+func (s *SortField) String() string {
+	if s.Descending {
+		return "-" + s.Field
+	}
+
+	return s.Field
+}
+
+// ParseSort loads the sort query param, a comma-separated list of fields prefixed with
+// - for descending order (e.g. sort=-created,name)
+//
+// If the param is empty the default fields are returned. If a field is not in the
+// allowlist or used twice a BadRequest-Response is returned, else the response is nil
+func ParseSort(request *http.Request, allowedFields []string, defaultFields ...*SortField) ([]*SortField, IResponse) {
+	fields := []*SortField{}
+	used := map[string]bool{}
+	fieldErrors := []*FieldError{}
+
+	for _, value := range request.URL.Query()[ParamSort] {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			field := &SortField{}
+
+			switch part[0] {
+			case '-':
+				field.Descending = true
+				field.Field = part[1:]
+			case '+':
+				field.Field = part[1:]
+			default:
+				field.Field = part
+			}
+
+			_, err := parseEnum(allowedFields)(field.Field)
+			if err != nil {
+				fieldErrors = append(fieldErrors, &FieldError{
+					Field:   ParamSort,
+					Source:  FieldSourceQuery,
+					Rule:    ValidationRuleEnum,
+					Message: fmt.Sprintf("invalid field '%s': %s", field.Field, err),
+				})
+
+				continue
+			}
+
+			if used[field.Field] {
+				fieldErrors = append(fieldErrors, &FieldError{
+					Field:   ParamSort,
+					Source:  FieldSourceQuery,
+					Rule:    ValidationRuleEnum,
+					Message: fmt.Sprintf("field '%s' is used more than once", field.Field),
+				})
+
+				continue
+			}
+
+			used[field.Field] = true
+			fields = append(fields, field)
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, invalidQueryParams(request, fieldErrors)
+	}
+
+	if len(fields) == 0 {
+		return defaultFields, nil
+	}
+
+	return fields, nil
+}
+
+// FilterOperator is the comparison of a Filter
+type FilterOperator string
+
+// Operators of filter expressions
+const (
+	FilterOperatorEq   FilterOperator = "eq"
+	FilterOperatorNe   FilterOperator = "ne"
+	FilterOperatorLt   FilterOperator = "lt"
+	FilterOperatorLte  FilterOperator = "lte"
+	FilterOperatorGt   FilterOperator = "gt"
+	FilterOperatorGte  FilterOperator = "gte"
+	FilterOperatorLike FilterOperator = "like"
+	// FilterOperatorIn matches any of multiple values separated by |
+	FilterOperatorIn FilterOperator = "in"
+)
+
+// FilterField is a field which can be filtered by
+type FilterField struct {
+	Name string
+	// Type is used for parsing the values (defaults to ParamTypeString)
+	Type ParamType
+	// AllowedValues are the values of ParamTypeEnum
+	AllowedValues []string
+	// Operators are the allowed operators, defaults to eq, ne and in plus lt, lte, gt
+	// and gte for numbers, times and dates and like for strings
+	Operators []FilterOperator
+}
+
+func (f *FilterField) operators() []FilterOperator {
+	if len(f.Operators) > 0 {
+		return f.Operators
+	}
+
+	operators := []FilterOperator{FilterOperatorEq, FilterOperatorNe, FilterOperatorIn}
+
+	switch f.Type {
+	case ParamTypeInt64, ParamTypeFloat64, ParamTypeTime, ParamTypeDate, ParamTypeDuration:
+		operators = append(operators, FilterOperatorLt, FilterOperatorLte, FilterOperatorGt, FilterOperatorGte)
+	case ParamTypeString, "":
+		operators = append(operators, FilterOperatorLike)
+	}
+
+	return operators
+}
+
+// Filter is a parsed filter expression
+type Filter struct {
+	Field    string
+	Operator FilterOperator
+	// Values contains the parsed values (e.g. int64 for ParamTypeInt64), only
+	// FilterOperatorIn can have multiple values
+	Values []interface{}
+}
+
+// Value returns the first value of the filter
+func (f *Filter) Value() interface{} {
+	return f.Values[0]
+}
+
+// parseFilter parses a single filter expression
+func parseFilter(expression string, fields map[string]*FilterField) (*Filter, error) {
+	name, rest, ok := strings.Cut(expression, ":")
+	operatorStr, valueStr, ok2 := strings.Cut(rest, ":")
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("invalid expression '%s': expected field:operator:value", expression)
+	}
+
+	field, ok := fields[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid field '%s'", name)
+	}
+
+	operator := FilterOperator(operatorStr)
+	allowed := false
+
+	for _, allowedOperator := range field.operators() {
+		if operator == allowedOperator {
+			allowed = true
+
+			break
+		}
+	}
+
+	if !allowed {
+		return nil, fmt.Errorf("invalid operator '%s' for field '%s'", operatorStr, name)
+	}
+
+	valueStrs := []string{valueStr}
+	if operator == FilterOperatorIn {
+		valueStrs = strings.Split(valueStr, "|")
+	}
+
+	filter := &Filter{
+		Field:    name,
+		Operator: operator,
+		Values:   make([]interface{}, len(valueStrs)),
+	}
+
+	for i, valueStr := range valueStrs {
+		value, err := field.Type.parseValue(valueStr, field.AllowedValues)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value '%s' for field '%s': %s", valueStr, name, err)
+		}
+
+		filter.Values[i] = value
+	}
+
+	return filter, nil
+}
+
+// ParseFilters loads the filter query params in the format field:operator:value
+// (e.g. filter=status:in:active|pending&filter=created:gte:2024-01-31T00:00:00Z)
+//
+// If a field is not in the allowlist, the operator is not allowed for the field or a
+// value can't be parsed a BadRequest-Response is returned, else the response is nil
+func ParseFilters(request *http.Request, fields ...*FilterField) ([]*Filter, IResponse) {
+	fieldsByName := map[string]*FilterField{}
+	for _, field := range fields {
+		fieldsByName[field.Name] = field
+	}
+
+	filters := []*Filter{}
+	fieldErrors := []*FieldError{}
+
+	for _, expression := range request.URL.Query()[ParamFilter] {
+		if expression == "" {
+			continue
+		}
+
+		filter, err := parseFilter(expression, fieldsByName)
+		if err != nil {
+			fieldErrors = append(fieldErrors, &FieldError{
+				Field:   ParamFilter,
+				Source:  FieldSourceQuery,
+				Rule:    ValidationRuleType,
+				Message: err.Error(),
+			})
+
+			continue
+		}
+
+		filters = append(filters, filter)
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, invalidQueryParams(request, fieldErrors)
+	}
+
+	return filters, nil
+}
+
+// PageEnvelope is the json body of paginated list responses
+type PageEnvelope struct {
+	Items      interface{} `json:"items"`
+	Limit      int         `json:"limit"`
+	Offset     *int        `json:"offset,omitempty"`
+	Total      *int64      `json:"total,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+}
+
+// pageLink returns a Link header value for the request's url with changed query params
+func pageLink(request *http.Request, rel string, params map[string]string) string {
+	query := request.URL.Query()
+
+	for key, value := range params {
+		if value == "" {
+			query.Del(key)
+
+			continue
+		}
+
+		query.Set(key, value)
+	}
+
+	link := url.URL{
+		Path:     request.URL.Path,
+		RawQuery: query.Encode(),
+	}
+
+	return fmt.Sprintf("<%s>; rel=\"%s\"", link.String(), rel)
+}
+
+// pageItems converts nil slices to empty slices, so they are encoded as [] instead of null
+func pageItems(items interface{}) interface{} {
+	value := reflect.ValueOf(items)
+	if value.Kind() == reflect.Slice && value.IsNil() {
+		return reflect.MakeSlice(value.Type(), 0, 0).Interface()
+	}
+
+	return items
+}
+
+func pageJSON(request *http.Request, envelope *PageEnvelope, links []string) *Response {
+	resp := JSON(request, envelope)
+
+	if len(links) > 0 {
+		resp.WithHeader("Link", strings.Join(links, ", "))
+	}
+
+	return resp
+}
+
+// OffsetPageJSON creates a json response containing a page of items in a PageEnvelope
+//
+// Link headers for the first, previous and next page are added. If the total is unknown
+// (invalid null.Int) a next page is assumed if the page is full.
+func OffsetPageJSON(request *http.Request, page *OffsetPage, items interface{}, total null.Int) *Response {
+	count := 0
+	if value := reflect.ValueOf(items); value.Kind() == reflect.Slice {
+		count = value.Len()
+	}
+
+	limitStr := strconv.Itoa(page.Limit)
+	links := []string{}
+
+	if page.Offset > 0 {
+		links = append(links, pageLink(request, "first", map[string]string{ParamLimit: limitStr, ParamOffset: ""}))
+
+		prevOffset := page.Offset - page.Limit
+		if prevOffset < 0 {
+			prevOffset = 0
+		}
+
+		links = append(links, pageLink(request, "prev", map[string]string{ParamLimit: limitStr, ParamOffset: strconv.Itoa(prevOffset)}))
+	}
+
+	nextOffset := page.Offset + page.Limit
+
+	hasNext := count >= page.Limit
+	if total.Valid {
+		hasNext = int64(nextOffset) < total.Int64
+	}
+
+	// No next page if the next offset would overflow
+	if page.Offset > math.MaxInt-page.Limit {
+		hasNext = false
+	}
+
+	if hasNext {
+		links = append(links, pageLink(request, "next", map[string]string{ParamLimit: limitStr, ParamOffset: strconv.Itoa(nextOffset)}))
+	}
+
+	return pageJSON(request, &PageEnvelope{
+		Items:  pageItems(items),
+		Limit:  page.Limit,
+		Offset: &page.Offset,
+		Total:  total.Ptr(),
+	}, links)
+}
+
+// CursorPageJSON creates a json response containing a page of items in a PageEnvelope
+//
+// Link headers for the previous and next page are added for non-empty cursors (see
+// EncodeCursor).
+func CursorPageJSON(request *http.Request, page *CursorPage, items interface{}, nextCursor string, prevCursor string) *Response {
+	limitStr := strconv.Itoa(page.Limit)
+	links := []string{}
+
+	if prevCursor != "" {
+		links = append(links, pageLink(request, "prev", map[string]string{ParamLimit: limitStr, ParamCursor: prevCursor}))
+	}
+
+	if nextCursor != "" {
+		links = append(links, pageLink(request, "next", map[string]string{ParamLimit: limitStr, ParamCursor: nextCursor}))
+	}
+
+	return pageJSON(request, &PageEnvelope{
+		Items:      pageItems(items),
+		Limit:      page.Limit,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
+	}, links)
+}
@@ -0,0 +1,159 @@
+package gousuchi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestParseOffsetPage(t *testing.T) {
+	page, resp := ParseOffsetPage(httptest.NewRequest(http.MethodGet, "/items", nil), nil)
+	require.Nil(t, resp)
+	assert.Equal(t, &OffsetPage{Limit: DefaultPageLimit, Offset: 0}, page)
+
+	page, resp = ParseOffsetPage(httptest.NewRequest(http.MethodGet, "/items?limit=50&offset=100", nil), &PaginationOptions{MaxLimit: 50})
+	require.Nil(t, resp)
+	assert.Equal(t, &OffsetPage{Limit: 50, Offset: 100}, page)
+
+	_, resp = ParseOffsetPage(httptest.NewRequest(http.MethodGet, "/items?limit=51&offset=-1", nil), &PaginationOptions{MaxLimit: 50})
+	require.NotNil(t, resp)
+
+	responseError := resp.(*ResponseError)
+	assert.Equal(t, http.StatusBadRequest, responseError.StatusCode)
+	require.Len(t, responseError.FieldErrors, 2)
+	assert.Equal(t, ValidationRuleMax, responseError.FieldErrors[0].Rule)
+	assert.Equal(t, ParamOffset, responseError.FieldErrors[1].Field)
+	assert.Equal(t, ValidationRuleMin, responseError.FieldErrors[1].Rule)
+
+	_, resp = ParseOffsetPage(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/items?offset=%d", int64(DefaultPageMaxOffset)+1), nil), nil)
+	require.NotNil(t, resp)
+	assert.Equal(t, ValidationRuleMax, resp.(*ResponseError).FieldErrors[0].Rule)
+
+	_, resp = ParseOffsetPage(httptest.NewRequest(http.MethodGet, "/items?limit=abc", nil), nil)
+	require.NotNil(t, resp)
+}
+
+func TestParseCursorPage(t *testing.T) {
+	type cursor struct {
+		Created time.Time `json:"created"`
+		ID      int64     `json:"id"`
+	}
+
+	encoded, err := EncodeCursor(&cursor{Created: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), ID: 12})
+	require.NoError(t, err)
+
+	decoded := &cursor{}
+
+	page, resp := ParseCursorPage(httptest.NewRequest(http.MethodGet, "/items?limit=5&cursor="+encoded, nil), nil, decoded)
+	require.Nil(t, resp)
+	assert.Equal(t, 5, page.Limit)
+	assert.Equal(t, encoded, page.Cursor)
+	assert.Equal(t, int64(12), decoded.ID)
+
+	_, resp = ParseCursorPage(httptest.NewRequest(http.MethodGet, "/items?cursor=invalid!", nil), nil, decoded)
+	require.NotNil(t, resp)
+	assert.Equal(t, ParamCursor, resp.(*ResponseError).FieldErrors[0].Field)
+}
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"name", "created"}
+
+	fields, resp := ParseSort(httptest.NewRequest(http.MethodGet, "/items?sort=-created,name", nil), allowed)
+	require.Nil(t, resp)
+	assert.Equal(t, []*SortField{{Field: "created", Descending: true}, {Field: "name"}}, fields)
+	assert.Equal(t, "-created", fields[0].String())
+
+	fields, resp = ParseSort(httptest.NewRequest(http.MethodGet, "/items", nil), allowed, &SortField{Field: "name"})
+	require.Nil(t, resp)
+	assert.Equal(t, []*SortField{{Field: "name"}}, fields)
+
+	_, resp = ParseSort(httptest.NewRequest(http.MethodGet, "/items?sort=password", nil), allowed)
+	require.NotNil(t, resp)
+	assert.Equal(t, ValidationRuleEnum, resp.(*ResponseError).FieldErrors[0].Rule)
+
+	_, resp = ParseSort(httptest.NewRequest(http.MethodGet, "/items?sort=name,-name", nil), allowed)
+	require.NotNil(t, resp)
+}
+
+func TestParseFilters(t *testing.T) {
+	fields := []*FilterField{
+		{Name: "name"},
+		{Name: "price", Type: ParamTypeFloat64},
+		{Name: "created", Type: ParamTypeTime},
+		{Name: "status", Type: ParamTypeEnum, AllowedValues: []string{"active", "pending", "deleted"}},
+	}
+
+	filters, resp := ParseFilters(
+		httptest.NewRequest(http.MethodGet, "/items?filter=status:in:active|pending&filter=price:gte:9.5&filter=created:lt:2024-01-31T12:00:00Z&filter=name:like:test", nil),
+		fields...,
+	)
+	require.Nil(t, resp)
+	require.Len(t, filters, 4)
+
+	assert.Equal(t, &Filter{Field: "status", Operator: FilterOperatorIn, Values: []interface{}{"active", "pending"}}, filters[0])
+	assert.Equal(t, 9.5, filters[1].Value())
+	assert.Equal(t, FilterOperatorGte, filters[1].Operator)
+	assert.Equal(t, time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), filters[2].Value())
+	assert.Equal(t, "test", filters[3].Value())
+
+	for _, target := range []string{
+		"/items?filter=secret:eq:1",
+		"/items?filter=status:like:act",
+		"/items?filter=price:eq:abc",
+		"/items?filter=status:in:active|unknown",
+		"/items?filter=name",
+	} {
+		_, resp = ParseFilters(httptest.NewRequest(http.MethodGet, target, nil), fields...)
+		assert.NotNil(t, resp, target)
+	}
+}
+
+func TestOffsetPageJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/items?limit=10&offset=10&sort=name", nil)
+
+	writer := httptest.NewRecorder()
+	require.Nil(t, OffsetPageJSON(req, &OffsetPage{Limit: 10, Offset: 10}, []string{"a"}, null.IntFrom(30)).Write(writer))
+
+	assert.Equal(
+		t,
+		`</items?limit=10&sort=name>; rel="first", </items?limit=10&offset=0&sort=name>; rel="prev", </items?limit=10&offset=20&sort=name>; rel="next"`,
+		writer.Header().Get("Link"),
+	)
+
+	body := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(writer.Body.Bytes(), &body))
+	assert.Equal(t, map[string]interface{}{"items": []interface{}{"a"}, "limit": 10.0, "offset": 10.0, "total": 30.0}, body)
+
+	// Last page with unknown total and nil items
+	var items []string
+
+	writer = httptest.NewRecorder()
+	require.Nil(t, OffsetPageJSON(httptest.NewRequest(http.MethodGet, "/items", nil), &OffsetPage{Limit: 10}, items, null.Int{}).Write(writer))
+
+	assert.Empty(t, writer.Header().Get("Link"))
+	assert.JSONEq(t, `{"items":[],"limit":10,"offset":0}`, writer.Body.String())
+
+	// Full page without next offset
+	writer = httptest.NewRecorder()
+	require.Nil(t, OffsetPageJSON(httptest.NewRequest(http.MethodGet, "/items", nil), &OffsetPage{Limit: 10, Offset: math.MaxInt - 5}, []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}, null.Int{}).Write(writer))
+
+	assert.NotContains(t, writer.Header().Get("Link"), `rel="next"`)
+}
+
+func TestCursorPageJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/items?cursor=abc", nil)
+
+	writer := httptest.NewRecorder()
+	require.Nil(t, CursorPageJSON(req, &CursorPage{Limit: 2, Cursor: "abc"}, []int{1, 2}, "def", "").Write(writer))
+
+	assert.Equal(t, `</items?cursor=def&limit=2>; rel="next"`, writer.Header().Get("Link"))
+	assert.JSONEq(t, `{"items":[1,2],"limit":2,"next_cursor":"def"}`, writer.Body.String())
+}
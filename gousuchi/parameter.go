@@ -1,11 +1,14 @@
 package gousuchi
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"gopkg.in/guregu/null.v4"
 )
 
@@ -207,3 +210,376 @@ func URLParamInt64Slice(request *http.Request, name string) ([]int64, IResponse)
 
 	return values, nil
 }
+
+// DateLayout is the layout of date parameters (e.g. 2024-01-31)
+const DateLayout = "2006-01-02"
+
+type paramSource struct {
+	kind string
+	get  func(request *http.Request, name string) string
+}
+
+var (
+	paramSourceQuery = &paramSource{
+		kind: "query",
+		get: func(request *http.Request, name string) string {
+			return request.URL.Query().Get(name)
+		},
+	}
+	paramSourceURL = &paramSource{
+		kind: "url",
+		get:  chi.URLParam,
+	}
+)
+
+// requiredParam loads a parameter and parses it, a BadRequest-Response is returned on errors
+func requiredParam[T any](request *http.Request, source *paramSource, name string, parse func(valueStr string) (T, error)) (T, IResponse) {
+	valueStr := source.get(request, name)
+
+	value, err := parse(valueStr)
+	if err != nil {
+		var empty T
+
+		return empty, BadRequest(request, "Invalid %s param %s (value: '%s'): %s", source.kind, name, valueStr, err)
+	}
+
+	return value, nil
+}
+
+// optionalParam loads a parameter and parses it if it is not empty, a BadRequest-Response
+// is returned on errors
+func optionalParam[T any](request *http.Request, source *paramSource, name string, parse func(valueStr string) (T, error)) (T, bool, IResponse) {
+	var empty T
+
+	if source.get(request, name) == "" {
+		return empty, false, nil
+	}
+
+	value, resp := requiredParam(request, source, name, parse)
+	if resp != nil {
+		return empty, false, resp
+	}
+
+	return value, true, nil
+}
+
+func parseFloat64(valueStr string) (float64, error) {
+	return strconv.ParseFloat(valueStr, 64)
+}
+
+func parseTime(valueStr string) (time.Time, error) {
+	return time.Parse(time.RFC3339, valueStr)
+}
+
+func parseDate(valueStr string) (time.Time, error) {
+	return time.Parse(DateLayout, valueStr)
+}
+
+func parseEnum(allowedValues []string) func(valueStr string) (string, error) {
+	return func(valueStr string) (string, error) {
+		for _, allowedValue := range allowedValues {
+			if valueStr == allowedValue {
+				return valueStr, nil
+			}
+		}
+
+		return "", fmt.Errorf("must be one of %s", strings.Join(allowedValues, ", "))
+	}
+}
+
+// QueryParamFloat64 loads a parameter from the url's query and parses it as float64
+//
+// If the parameter is not a valid float64 a BadRequest-Response is returned, else
+// the response is nil
+func QueryParamFloat64(request *http.Request, name string) (float64, IResponse) {
+	return requiredParam(request, paramSourceQuery, name, parseFloat64)
+}
+
+// OptionalQueryParamFloat64 loads a parameter from the url's query and parses it as float64
+//
+// If the parameter is not empty and not a valid float64 a BadRequest-Response is returned, else
+// the response is nil
+func OptionalQueryParamFloat64(request *http.Request, name string) (null.Float, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceQuery, name, parseFloat64)
+
+	return null.NewFloat(value, ok), resp
+}
+
+// URLParamFloat64 loads a parameter from the url and parses it as float64
+//
+// If the parameter is not a valid float64 a BadRequest-Response is returned, else
+// the response is nil
+func URLParamFloat64(request *http.Request, name string) (float64, IResponse) {
+	return requiredParam(request, paramSourceURL, name, parseFloat64)
+}
+
+// OptionalURLParamFloat64 loads a parameter from the url and parses it as float64
+//
+// If the parameter is not empty and not a valid float64 a BadRequest-Response is returned, else
+// the response is nil
+func OptionalURLParamFloat64(request *http.Request, name string) (null.Float, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceURL, name, parseFloat64)
+
+	return null.NewFloat(value, ok), resp
+}
+
+// QueryParamUUID loads a parameter from the url's query and parses it as UUID
+//
+// If the parameter is not a valid UUID a BadRequest-Response is returned, else
+// the response is nil
+func QueryParamUUID(request *http.Request, name string) (uuid.UUID, IResponse) {
+	return requiredParam(request, paramSourceQuery, name, uuid.Parse)
+}
+
+// OptionalQueryParamUUID loads a parameter from the url's query and parses it as UUID
+//
+// If the parameter is not empty and not a valid UUID a BadRequest-Response is returned, else
+// the response is nil
+func OptionalQueryParamUUID(request *http.Request, name string) (uuid.NullUUID, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceQuery, name, uuid.Parse)
+
+	return uuid.NullUUID{UUID: value, Valid: ok}, resp
+}
+
+// URLParamUUID loads a parameter from the url and parses it as UUID
+//
+// If the parameter is not a valid UUID a BadRequest-Response is returned, else
+// the response is nil
+func URLParamUUID(request *http.Request, name string) (uuid.UUID, IResponse) {
+	return requiredParam(request, paramSourceURL, name, uuid.Parse)
+}
+
+// OptionalURLParamUUID loads a parameter from the url and parses it as UUID
+//
+// If the parameter is not empty and not a valid UUID a BadRequest-Response is returned, else
+// the response is nil
+func OptionalURLParamUUID(request *http.Request, name string) (uuid.NullUUID, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceURL, name, uuid.Parse)
+
+	return uuid.NullUUID{UUID: value, Valid: ok}, resp
+}
+
+// QueryParamTime loads a parameter from the url's query and parses it as RFC3339 timestamp
+//
+// If the parameter is not a valid timestamp a BadRequest-Response is returned, else
+// the response is nil
+func QueryParamTime(request *http.Request, name string) (time.Time, IResponse) {
+	return requiredParam(request, paramSourceQuery, name, parseTime)
+}
+
+// OptionalQueryParamTime loads a parameter from the url's query and parses it as RFC3339 timestamp
+//
+// If the parameter is not empty and not a valid timestamp a BadRequest-Response is returned, else
+// the response is nil
+func OptionalQueryParamTime(request *http.Request, name string) (null.Time, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceQuery, name, parseTime)
+
+	return null.NewTime(value, ok), resp
+}
+
+// URLParamTime loads a parameter from the url and parses it as RFC3339 timestamp
+//
+// If the parameter is not a valid timestamp a BadRequest-Response is returned, else
+// the response is nil
+func URLParamTime(request *http.Request, name string) (time.Time, IResponse) {
+	return requiredParam(request, paramSourceURL, name, parseTime)
+}
+
+// OptionalURLParamTime loads a parameter from the url and parses it as RFC3339 timestamp
+//
+// If the parameter is not empty and not a valid timestamp a BadRequest-Response is returned, else
+// the response is nil
+func OptionalURLParamTime(request *http.Request, name string) (null.Time, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceURL, name, parseTime)
+
+	return null.NewTime(value, ok), resp
+}
+
+// QueryParamDate loads a parameter from the url's query and parses it as date (see DateLayout)
+//
+// The returned time is midnight UTC. If the parameter is not a valid date a BadRequest-Response
+// is returned, else the response is nil
+func QueryParamDate(request *http.Request, name string) (time.Time, IResponse) {
+	return requiredParam(request, paramSourceQuery, name, parseDate)
+}
+
+// OptionalQueryParamDate loads a parameter from the url's query and parses it as date (see DateLayout)
+//
+// If the parameter is not empty and not a valid date a BadRequest-Response is returned, else
+// the response is nil
+func OptionalQueryParamDate(request *http.Request, name string) (null.Time, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceQuery, name, parseDate)
+
+	return null.NewTime(value, ok), resp
+}
+
+// URLParamDate loads a parameter from the url and parses it as date (see DateLayout)
+//
+// The returned time is midnight UTC. If the parameter is not a valid date a BadRequest-Response
+// is returned, else the response is nil
+func URLParamDate(request *http.Request, name string) (time.Time, IResponse) {
+	return requiredParam(request, paramSourceURL, name, parseDate)
+}
+
+// OptionalURLParamDate loads a parameter from the url and parses it as date (see DateLayout)
+//
+// If the parameter is not empty and not a valid date a BadRequest-Response is returned, else
+// the response is nil
+func OptionalURLParamDate(request *http.Request, name string) (null.Time, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceURL, name, parseDate)
+
+	return null.NewTime(value, ok), resp
+}
+
+// QueryParamDuration loads a parameter from the url's query and parses it as duration (e.g. 1h30m)
+//
+// If the parameter is not a valid duration a BadRequest-Response is returned, else
+// the response is nil
+func QueryParamDuration(request *http.Request, name string) (time.Duration, IResponse) {
+	return requiredParam(request, paramSourceQuery, name, time.ParseDuration)
+}
+
+// OptionalQueryParamDuration loads a parameter from the url's query and parses it as duration (e.g. 1h30m)
+//
+// The value is returned in nanoseconds. If the parameter is not empty and not a valid duration
+// a BadRequest-Response is returned, else the response is nil
+func OptionalQueryParamDuration(request *http.Request, name string) (null.Int, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceQuery, name, time.ParseDuration)
+
+	return null.NewInt(int64(value), ok), resp
+}
+
+// URLParamDuration loads a parameter from the url and parses it as duration (e.g. 1h30m)
+//
+// If the parameter is not a valid duration a BadRequest-Response is returned, else
+// the response is nil
+func URLParamDuration(request *http.Request, name string) (time.Duration, IResponse) {
+	return requiredParam(request, paramSourceURL, name, time.ParseDuration)
+}
+
+// OptionalURLParamDuration loads a parameter from the url and parses it as duration (e.g. 1h30m)
+//
+// The value is returned in nanoseconds. If the parameter is not empty and not a valid duration
+// a BadRequest-Response is returned, else the response is nil
+func OptionalURLParamDuration(request *http.Request, name string) (null.Int, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceURL, name, time.ParseDuration)
+
+	return null.NewInt(int64(value), ok), resp
+}
+
+// QueryParamEnum loads a parameter from the url's query and checks it against the allowed values
+//
+// If the parameter is not one of the allowed values a BadRequest-Response is returned, else
+// the response is nil
+func QueryParamEnum(request *http.Request, name string, allowedValues []string) (string, IResponse) {
+	return requiredParam(request, paramSourceQuery, name, parseEnum(allowedValues))
+}
+
+// OptionalQueryParamEnum loads a parameter from the url's query and checks it against the allowed values
+//
+// If the parameter is not empty and not one of the allowed values a BadRequest-Response is returned, else
+// the response is nil
+func OptionalQueryParamEnum(request *http.Request, name string, allowedValues []string) (null.String, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceQuery, name, parseEnum(allowedValues))
+
+	return null.NewString(value, ok), resp
+}
+
+// URLParamEnum loads a parameter from the url and checks it against the allowed values
+//
+// If the parameter is not one of the allowed values a BadRequest-Response is returned, else
+// the response is nil
+func URLParamEnum(request *http.Request, name string, allowedValues []string) (string, IResponse) {
+	return requiredParam(request, paramSourceURL, name, parseEnum(allowedValues))
+}
+
+// OptionalURLParamEnum loads a parameter from the url and checks it against the allowed values
+//
+// If the parameter is not empty and not one of the allowed values a BadRequest-Response is returned, else
+// the response is nil
+func OptionalURLParamEnum(request *http.Request, name string, allowedValues []string) (null.String, IResponse) {
+	value, ok, resp := optionalParam(request, paramSourceURL, name, parseEnum(allowedValues))
+
+	return null.NewString(value, ok), resp
+}
+
+// QueryParamStringSlice loads all values of a repeated parameter from the url's query (e.g. ?tag=a&tag=b)
+//
+// If the parameter is missing a BadRequest-Response is returned, else
+// the response is nil
+func QueryParamStringSlice(request *http.Request, name string) ([]string, IResponse) {
+	values, _ := OptionalQueryParamStringSlice(request, name)
+	if len(values) == 0 {
+		return []string{}, BadRequest(request, "Empty query param %s", name)
+	}
+
+	return values, nil
+}
+
+// OptionalQueryParamStringSlice loads all values of a repeated parameter from the url's query (e.g. ?tag=a&tag=b)
+//
+// Empty values are skipped. Response is always nil and only returned for compatiblity here
+func OptionalQueryParamStringSlice(request *http.Request, name string) ([]string, IResponse) {
+	values := []string{}
+
+	for _, value := range request.URL.Query()[name] {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values, nil
+}
+
+// QueryParamInt64Slice loads all values of a repeated parameter from the url's query (e.g. ?id=1&id=2)
+// and parses them as int64
+//
+// If the parameter is missing or a value is not a valid int64 a BadRequest-Response is returned, else
+// the response is nil
+func QueryParamInt64Slice(request *http.Request, name string) ([]int64, IResponse) {
+	values, resp := OptionalQueryParamInt64Slice(request, name)
+	if resp != nil {
+		return []int64{}, resp
+	}
+
+	if len(values) == 0 {
+		return []int64{}, BadRequest(request, "Empty query param %s", name)
+	}
+
+	return values, nil
+}
+
+// OptionalQueryParamInt64Slice loads all values of a repeated parameter from the url's query (e.g. ?id=1&id=2)
+// and parses them as int64
+//
+// If a value is not a valid int64 a BadRequest-Response is returned, else
+// the response is nil
+func OptionalQueryParamInt64Slice(request *http.Request, name string) ([]int64, IResponse) {
+	valueStrs, _ := OptionalQueryParamStringSlice(request, name)
+
+	values := []int64{}
+
+	for i, valueStr := range valueStrs {
+		value, err := strconv.ParseInt(valueStr, 10, 64)
+		if err != nil {
+			return []int64{}, BadRequest(request, "Invalid query param %s (%d. value: '%s'): %s", name, i+1, valueStr, err)
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// URLParamStringSlice loads a parameter from the url and splits it as a comma-separated list
+//
+// If the parameter is empty a BadRequest-Response is returned, else
+// the response is nil
+func URLParamStringSlice(request *http.Request, name string) ([]string, IResponse) {
+	valueStr, resp := URLParamString(request, name)
+	if resp != nil {
+		return []string{}, resp
+	}
+
+	return strings.Split(valueStr, ","), nil
+}
@@ -0,0 +1,91 @@
+package gousuchi
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestQueryParamTypes(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test?price=1.5&id=8c4b3a9e-5f1d-4a57-9d2c-1e2f3a4b5c6d&from=2024-01-31T10:00:00Z&day=2024-01-31&ttl=1h30m&sort=asc&tag=a&tag=b&num=1&num=2", nil)
+
+	price, resp := QueryParamFloat64(req, "price")
+	assert.Nil(t, resp)
+	assert.Equal(t, 1.5, price)
+
+	id, resp := QueryParamUUID(req, "id")
+	assert.Nil(t, resp)
+	assert.Equal(t, uuid.MustParse("8c4b3a9e-5f1d-4a57-9d2c-1e2f3a4b5c6d"), id)
+
+	from, resp := QueryParamTime(req, "from")
+	assert.Nil(t, resp)
+	assert.Equal(t, time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC), from.UTC())
+
+	day, resp := OptionalQueryParamDate(req, "day")
+	assert.Nil(t, resp)
+	assert.Equal(t, null.TimeFrom(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)), day)
+
+	ttl, resp := OptionalQueryParamDuration(req, "ttl")
+	assert.Nil(t, resp)
+	assert.Equal(t, null.IntFrom(int64(90*time.Minute)), ttl)
+
+	sort, resp := QueryParamEnum(req, "sort", []string{"asc", "desc"})
+	assert.Nil(t, resp)
+	assert.Equal(t, "asc", sort)
+
+	tags, resp := QueryParamStringSlice(req, "tag")
+	assert.Nil(t, resp)
+	assert.Equal(t, []string{"a", "b"}, tags)
+
+	nums, resp := QueryParamInt64Slice(req, "num")
+	assert.Nil(t, resp)
+	assert.Equal(t, []int64{1, 2}, nums)
+}
+
+func TestOptionalQueryParamTypes(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test?price=abc&sort=up", nil)
+
+	price, resp := OptionalQueryParamFloat64(req, "price")
+	assert.NotNil(t, resp)
+	assert.False(t, price.Valid)
+
+	_, resp = OptionalQueryParamEnum(req, "sort", []string{"asc", "desc"})
+	assert.NotNil(t, resp)
+
+	id, resp := OptionalQueryParamUUID(req, "id")
+	assert.Nil(t, resp)
+	assert.False(t, id.Valid)
+
+	_, resp = QueryParamInt64Slice(req, "num")
+	assert.NotNil(t, resp)
+
+	nums, resp := OptionalQueryParamInt64Slice(req, "num")
+	assert.Nil(t, resp)
+	assert.Empty(t, nums)
+}
+
+func TestURLParamTypes(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+
+	routeContext := chi.NewRouteContext()
+	routeContext.URLParams.Add("id", "invalid")
+	routeContext.URLParams.Add("names", "a,b")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+
+	_, resp := URLParamUUID(req, "id")
+	assert.NotNil(t, resp)
+
+	names, resp := URLParamStringSlice(req, "names")
+	assert.Nil(t, resp)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	price, resp := OptionalURLParamFloat64(req, "price")
+	assert.Nil(t, resp)
+	assert.False(t, price.Valid)
+}
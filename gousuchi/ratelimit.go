@@ -0,0 +1,215 @@
+package gousuchi
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitAlgorithm defines how requests are counted by RateLimitMiddleware
+type RateLimitAlgorithm int
+
+const (
+	// RateLimitTokenBucket allows bursts of up to Limit requests, the bucket is
+	// refilled continuously within Window (default)
+	RateLimitTokenBucket RateLimitAlgorithm = iota
+	// RateLimitSlidingWindow allows up to Limit requests within the last Window
+	RateLimitSlidingWindow
+)
+
+// RateLimitKeyFunc returns the key requests are limited by, requests with an
+// empty key are not limited
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitOptions configures RateLimitMiddleware
+type RateLimitOptions struct {
+	// Name prefixes all keys, so multiple limits can share a store (e.g. "login")
+	Name      string
+	Algorithm RateLimitAlgorithm
+	Limit     int
+	Window    time.Duration
+	// Store defaults to a new MemoryRateLimitStore
+	Store IRateLimitStore
+	// Key defaults to the client's ip address without trusted proxies
+	Key RateLimitKeyFunc
+}
+
+// ParseTrustedProxies parses ip addresses and CIDR ranges of trusted proxies
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := []*net.IPNet{}
+
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy '%s'", proxy)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy '%s': %s", proxy, err)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP returns the ip address of the client
+//
+// The headers X-Real-IP and X-Forwarded-For are only used if the request was sent
+// by a trusted proxy. Trusted proxies in X-Forwarded-For are skipped.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	remoteAddr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteAddr = r.RemoteAddr
+	}
+
+	remoteIP := net.ParseIP(remoteAddr)
+	if remoteIP == nil || !isTrustedProxy(remoteIP, trustedProxies) {
+		return remoteAddr
+	}
+
+	realIP := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	if realIP != nil {
+		return realIP.String()
+	}
+
+	forwardedFor := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwardedFor[i]))
+		if ip == nil {
+			break
+		}
+
+		if !isTrustedProxy(ip, trustedProxies) {
+			return ip.String()
+		}
+	}
+
+	return remoteAddr
+}
+
+// RateLimitKeyByIP limits requests by the client's ip address (see ClientIP)
+func RateLimitKeyByIP(trustedProxies []*net.IPNet) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		return "ip:" + ClientIP(r, trustedProxies)
+	}
+}
+
+// RateLimitKeyByUser limits requests by the user id returned by getUserID, requests
+// without user id are limited by the fallback (if not nil)
+func RateLimitKeyByUser(getUserID func(r *http.Request) string, fallback RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		userID := getUserID(r)
+		if userID != "" {
+			return "user:" + userID
+		}
+
+		if fallback != nil {
+			return fallback(r)
+		}
+
+		return ""
+	}
+}
+
+// RateLimitMiddleware rejects requests exceeding the limit with a TooManyRequests
+// response including a Retry-After header
+//
+// The headers X-RateLimit-Limit and X-RateLimit-Remaining are added to all responses.
+// Requests are allowed if the store fails.
+func (c *AbstractController) RateLimitMiddleware(options RateLimitOptions) Middleware {
+	if options.Store == nil {
+		options.Store = NewMemoryRateLimitStore()
+	}
+
+	if options.Key == nil {
+		options.Key = RateLimitKeyByIP(nil)
+	}
+
+	if options.Limit < 1 {
+		options.Limit = 1
+	}
+
+	if options.Window <= 0 {
+		options.Window = time.Minute
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := options.Key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if options.Name != "" {
+				key = options.Name + ":" + key
+			}
+
+			var allowed bool
+			var remaining int
+			var retryAfter time.Duration
+			var err error
+
+			switch options.Algorithm {
+			case RateLimitSlidingWindow:
+				allowed, remaining, retryAfter, err = options.Store.SlidingWindow(key, options.Limit, options.Window, time.Now())
+			default:
+				allowed, remaining, retryAfter, err = options.Store.TokenBucket(key, options.Limit, options.Window, time.Now())
+			}
+			if err != nil {
+				c.GetLog(r).Warnf("Can't check rate limit for %s: %s", key, err)
+
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(options.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+			if !allowed {
+				retryAfterSeconds := int(math.Max(1, math.Ceil(retryAfter.Seconds())))
+
+				resp := TooManyRequests(r, "Rate limit of %d requests per %s exceeded for %s", options.Limit, options.Window, key).
+					WithHeader("Retry-After", strconv.Itoa(retryAfterSeconds))
+
+				resp.Write(w)
+				resp.Log(c.GetLog(r))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
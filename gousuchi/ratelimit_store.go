@@ -0,0 +1,145 @@
+package gousuchi
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// IRateLimitStore stores the state of rate limits
+//
+// Both methods consume one request for the key and return if it is allowed, the
+// number of remaining requests and the duration until the next request is allowed.
+// The interface only uses standard types, so stores can be implemented in other
+// modules (e.g. gousuredis.RateLimitStore) without depending on gousuchi.
+type IRateLimitStore interface {
+	TokenBucket(key string, limit int, window time.Duration, now time.Time) (bool, int, time.Duration, error)
+	SlidingWindow(key string, limit int, window time.Duration, now time.Time) (bool, int, time.Duration, error)
+}
+
+type memoryTokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+type memorySlidingWindow struct {
+	requests  []time.Time
+	expiresAt time.Time
+}
+
+// MemoryRateLimitStore is an IRateLimitStore keeping the rate limits in memory,
+// it is only suitable for a single replica
+type MemoryRateLimitStore struct {
+	tokenBuckets   map[string]*memoryTokenBucket
+	slidingWindows map[string]*memorySlidingWindow
+	lastCleanup    time.Time
+	mutex          sync.Mutex
+}
+
+var _ IRateLimitStore = (*MemoryRateLimitStore)(nil)
+
+// cleanup removes expired entries at most once per minute
+func (s *MemoryRateLimitStore) cleanup(now time.Time) {
+	if now.Sub(s.lastCleanup) < time.Minute {
+		return
+	}
+
+	s.lastCleanup = now
+
+	for key, bucket := range s.tokenBuckets {
+		if now.After(bucket.expiresAt) {
+			delete(s.tokenBuckets, key)
+		}
+	}
+
+	for key, window := range s.slidingWindows {
+		if now.After(window.expiresAt) {
+			delete(s.slidingWindows, key)
+		}
+	}
+}
+
+// TokenBucket consumes a token from a bucket holding up to limit tokens, which is
+// refilled completely within the window
+func (s *MemoryRateLimitStore) TokenBucket(key string, limit int, window time.Duration, now time.Time) (bool, int, time.Duration, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cleanup(now)
+
+	bucket, ok := s.tokenBuckets[key]
+	if !ok {
+		bucket = &memoryTokenBucket{
+			tokens:    float64(limit),
+			updatedAt: now,
+		}
+
+		s.tokenBuckets[key] = bucket
+	}
+
+	rate := float64(limit) / float64(window)
+
+	if now.After(bucket.updatedAt) {
+		bucket.tokens = math.Min(float64(limit), bucket.tokens+float64(now.Sub(bucket.updatedAt))*rate)
+		bucket.updatedAt = now
+	}
+
+	bucket.expiresAt = now.Add(window)
+
+	if bucket.tokens < 1 {
+		retryAfter := time.Duration(math.Ceil((1 - bucket.tokens) / rate))
+
+		return false, 0, retryAfter, nil
+	}
+
+	bucket.tokens--
+
+	return true, int(bucket.tokens), 0, nil
+}
+
+// SlidingWindow allows up to limit requests within the window before now
+func (s *MemoryRateLimitStore) SlidingWindow(key string, limit int, window time.Duration, now time.Time) (bool, int, time.Duration, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cleanup(now)
+
+	slidingWindow, ok := s.slidingWindows[key]
+	if !ok {
+		slidingWindow = &memorySlidingWindow{}
+
+		s.slidingWindows[key] = slidingWindow
+	}
+
+	start := now.Add(-window)
+
+	first := 0
+	for first < len(slidingWindow.requests) && !slidingWindow.requests[first].After(start) {
+		first++
+	}
+
+	slidingWindow.requests = slidingWindow.requests[first:]
+
+	if len(slidingWindow.requests) >= limit {
+		retryAfter := time.Duration(0)
+		if len(slidingWindow.requests) > 0 {
+			retryAfter = slidingWindow.requests[0].Add(window).Sub(now)
+		}
+
+		return false, 0, retryAfter, nil
+	}
+
+	slidingWindow.requests = append(slidingWindow.requests, now)
+	slidingWindow.expiresAt = now.Add(window)
+
+	return true, limit - len(slidingWindow.requests), 0, nil
+}
+
+// NewMemoryRateLimitStore creates a new initialized instance of MemoryRateLimitStore
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		tokenBuckets:   map[string]*memoryTokenBucket{},
+		slidingWindows: map[string]*memorySlidingWindow{},
+	}
+}
@@ -0,0 +1,114 @@
+package gousuchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRateLimitStoreTokenBucket(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		allowed, remaining, _, err := store.TokenBucket("key", 2, time.Second, now)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 1-i, remaining)
+	}
+
+	allowed, _, retryAfter, err := store.TokenBucket("key", 2, time.Second, now)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	allowed, _, _, err = store.TokenBucket("key", 2, time.Second, now.Add(500*time.Millisecond))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestMemoryRateLimitStoreSlidingWindow(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Now()
+
+	allowed, remaining, _, err := store.SlidingWindow("key", 2, time.Second, now)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, remaining)
+
+	allowed, _, _, err = store.SlidingWindow("key", 2, time.Second, now.Add(400*time.Millisecond))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, _, retryAfter, err := store.SlidingWindow("key", 2, time.Second, now.Add(500*time.Millisecond))
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	allowed, _, _, err = store.SlidingWindow("key", 2, time.Second, now.Add(1001*time.Millisecond))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestClientIP(t *testing.T) {
+	trustedProxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	assert.Equal(t, "203.0.113.1", ClientIP(req, trustedProxies))
+
+	req.RemoteAddr = "10.0.0.5:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 192.168.1.1")
+
+	assert.Equal(t, "198.51.100.1", ClientIP(req, trustedProxies))
+
+	req.Header.Set("X-Real-IP", "198.51.100.2")
+
+	assert.Equal(t, "198.51.100.2", ClientIP(req, trustedProxies))
+
+	_, err = ParseTrustedProxies([]string{"invalid"})
+	assert.Error(t, err)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	handler := c.RateLimitMiddleware(RateLimitOptions{
+		Name:      "login",
+		Algorithm: RateLimitSlidingWindow,
+		Limit:     1,
+		Window:    time.Minute,
+		Key: RateLimitKeyByUser(func(r *http.Request) string {
+			return r.Header.Get("X-User")
+		}, RateLimitKeyByIP(nil)),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.Header.Set("X-User", "user1")
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "0", writer.Header().Get("X-RateLimit-Remaining"))
+
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, writer.Code)
+	assert.Equal(t, "60", writer.Header().Get("Retry-After"))
+
+	// Other users and anonymous requests (by ip) are limited separately
+	writer = httptest.NewRecorder()
+	handler.ServeHTTP(writer, httptest.NewRequest("POST", "/login", nil))
+
+	assert.Equal(t, http.StatusOK, writer.Code)
+}
@@ -1,10 +1,12 @@
 package gousuchi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/indece-official/go-gousu/v2/gousu/logger"
 )
@@ -19,7 +21,11 @@ type ContentType string
 
 const (
 	ContentTypeApplicationJSON        ContentType = "application/json"
+	ContentTypeApplicationProblemJSON ContentType = "application/problem+json"
 	ContentTypeApplicationOctetStream ContentType = "application/octet-stream"
+	ContentTypeApplicationXML         ContentType = "application/xml"
+	ContentTypeApplicationMsgPack     ContentType = "application/msgpack"
+	ContentTypeApplicationProtobuf    ContentType = "application/x-protobuf"
 	ContentTypeApplicationPDF         ContentType = "application/pdf"
 	ContentTypeTextPlain              ContentType = "text/plain"
 	ContentTypeTextHTML               ContentType = "text/html"
@@ -39,6 +45,12 @@ type Response struct {
 	BodyReader      io.Reader
 	DetailedMessage string
 	DisableLogging  bool
+	// ETag is compared with If-None-Match (see WithETag)
+	ETag string
+	// LastModified is compared with If-Modified-Since (see WithLastModified)
+	LastModified     time.Time
+	bodyETag         bool
+	servedStatusCode int
 }
 
 var _ IResponse = (*Response)(nil)
@@ -89,6 +101,67 @@ func (r *Response) WithHeaders(headers http.Header) *Response {
 	return r
 }
 
+// WithETag sets the ETag of the response, it is quoted if necessary (e.g. ComputeETag(data))
+//
+// GET and HEAD requests with a matching If-None-Match header are answered with 304 Not Modified.
+func (r *Response) WithETag(etag string) *Response {
+	r.ETag = quoteETag(etag)
+
+	return r
+}
+
+// WithBodyETag sets the ETag of the response to a hash of its body (see WithETag),
+// it has no effect for responses with a body reader
+func (r *Response) WithBodyETag() *Response {
+	r.bodyETag = true
+
+	return r
+}
+
+// WithLastModified sets the time the content was last modified
+//
+// GET and HEAD requests without If-None-Match, but with an If-Modified-Since header
+// not before this time are answered with 304 Not Modified.
+func (r *Response) WithLastModified(lastModified time.Time) *Response {
+	r.LastModified = lastModified
+
+	return r
+}
+
+// WithCacheControl sets the Cache-Control header (e.g. CacheControlPrivate(time.Hour))
+func (r *Response) WithCacheControl(cacheControl string) *Response {
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+
+	r.Header.Set("Cache-Control", cacheControl)
+
+	return r
+}
+
+// seekableContent returns the body if it can be served with http.ServeContent
+//
+// Seekable body readers (e.g. *os.File) are always served this way to support Range
+// requests, bodies only if the response has an ETag or a modification time.
+func (r *Response) seekableContent(etag string) io.ReadSeeker {
+	if r.BodyReader != nil {
+		readSeeker, _ := r.BodyReader.(io.ReadSeeker)
+
+		return readSeeker
+	}
+
+	if etag != "" || !r.LastModified.IsZero() {
+		return bytes.NewReader(r.Body)
+	}
+
+	return nil
+}
+
+// Write writes the response
+//
+// Successful responses to GET and HEAD requests are served via http.ServeContent if the
+// body is seekable (see seekableContent), which answers conditional requests with 304 Not
+// Modified and Range requests with 206 Partial Content.
 func (r *Response) Write(w http.ResponseWriter) IResponse {
 	if r.responseError != nil {
 		return r.responseError
@@ -100,7 +173,46 @@ func (r *Response) Write(w http.ResponseWriter) IResponse {
 		}
 	}
 
+	etag := r.ETag
+	if r.bodyETag && r.BodyReader == nil {
+		etag = ComputeETag(r.Body)
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
 	w.Header().Set("Content-Type", string(r.ContentType))
+
+	if r.StatusCode == http.StatusOK &&
+		r.Request != nil &&
+		(r.Request.Method == http.MethodGet || r.Request.Method == http.MethodHead) {
+		content := r.seekableContent(etag)
+		if content != nil {
+			sw := newStatusWriter(w)
+
+			// ServeContent sets Last-Modified and evaluates all conditional headers
+			http.ServeContent(sw, r.Request, "", r.LastModified, content)
+
+			r.servedStatusCode = sw.StatusCode()
+
+			return nil
+		}
+
+		if !r.LastModified.IsZero() {
+			w.Header().Set("Last-Modified", r.LastModified.UTC().Format(http.TimeFormat))
+		}
+
+		if isNotModified(r.Request, etag, r.LastModified) {
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+
+			r.servedStatusCode = http.StatusNotModified
+
+			return nil
+		}
+	}
+
 	w.WriteHeader(r.StatusCode)
 
 	if r.BodyReader != nil {
@@ -122,7 +234,12 @@ func (r *Response) Log(log *logger.Log) {
 		message = "OK"
 	}
 
-	log.Infof("%s %s - %d %s", r.Request.Method, r.Request.RequestURI, r.StatusCode, message)
+	statusCode := r.StatusCode
+	if r.servedStatusCode != 0 {
+		statusCode = r.servedStatusCode
+	}
+
+	log.Infof("%s %s - %d %s", r.Request.Method, r.Request.RequestURI, statusCode, message)
 }
 
 func NewResponse(
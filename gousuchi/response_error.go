@@ -1,17 +1,31 @@
 package gousuchi
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/indece-official/go-gousu/v2/gousu/logger"
 )
 
+// LogFieldRequestID is the log field containing the id of a request, it is
+// included in error responses
+const LogFieldRequestID = "request_id"
+
+// ProblemTypeDefault is the problem type of errors without a specific type (RFC 7807)
+const ProblemTypeDefault = "about:blank"
+
 type ResponseError struct {
 	Request       *http.Request
 	StatusCode    int
 	PublicMessage string
+	PublicDetail  string
 	DetailedError error
+	FieldErrors   []*FieldError
+	// ProblemType is an URI identifying the type of the problem (defaults to ProblemTypeDefault)
+	ProblemType string
+	Extensions  map[string]interface{}
+	Header      http.Header
 }
 
 var _ IResponse = (*ResponseError)(nil)
@@ -20,9 +34,126 @@ func (r *ResponseError) GetRequest() *http.Request {
 	return r.Request
 }
 
+// WithFieldErrors adds errors of individual fields, which are returned in
+// the extension field "errors"
+func (r *ResponseError) WithFieldErrors(fieldErrors []*FieldError) *ResponseError {
+	r.FieldErrors = append(r.FieldErrors, fieldErrors...)
+
+	return r
+}
+
+// WithPublicDetail sets a message returned to the client as "detail"
+//
+// In contrast to the detailed message only logged, it must not contain internal information
+func (r *ResponseError) WithPublicDetail(publicDetail string, args ...interface{}) *ResponseError {
+	r.PublicDetail = fmt.Sprintf(publicDetail, args...)
+
+	return r
+}
+
+// WithProblemType sets the URI identifying the type of the problem
+func (r *ResponseError) WithProblemType(problemType string) *ResponseError {
+	r.ProblemType = problemType
+
+	return r
+}
+
+// WithExtension adds an extension field to the problem details
+func (r *ResponseError) WithExtension(key string, value interface{}) *ResponseError {
+	if r.Extensions == nil {
+		r.Extensions = map[string]interface{}{}
+	}
+
+	r.Extensions[key] = value
+
+	return r
+}
+
+// WithHeader adds a header to the response (e.g. Retry-After)
+func (r *ResponseError) WithHeader(key string, value string) *ResponseError {
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+
+	r.Header.Add(key, value)
+
+	return r
+}
+
+// Problem returns the problem details of the error (RFC 7807)
+func (r *ResponseError) Problem() map[string]interface{} {
+	problem := map[string]interface{}{}
+
+	for key, value := range r.Extensions {
+		problem[key] = value
+	}
+
+	problemType := r.ProblemType
+	if problemType == "" {
+		problemType = ProblemTypeDefault
+	}
+
+	problem["type"] = problemType
+	problem["title"] = r.PublicMessage
+	problem["status"] = r.StatusCode
+
+	if r.PublicDetail != "" {
+		problem["detail"] = r.PublicDetail
+	}
+
+	if len(r.FieldErrors) > 0 {
+		problem["errors"] = r.FieldErrors
+	}
+
+	if r.Request != nil {
+		problem["instance"] = r.Request.URL.Path
+
+		requestID, ok := logger.FromContext(r.Request.Context())[LogFieldRequestID]
+		if ok {
+			problem["request_id"] = requestID
+		}
+	}
+
+	return problem
+}
+
+// Write writes the error as application/problem+json (or application/json if
+// explicitly requested), or as text/plain if the client doesn't accept json
 func (r *ResponseError) Write(w http.ResponseWriter) IResponse {
+	for field, values := range r.Header {
+		w.Header()[field] = values
+	}
+
+	accept := ""
+	if r.Request != nil {
+		accept = r.Request.Header.Get("Accept")
+	}
+
+	contentType := negotiateContentType(accept, []string{
+		string(ContentTypeApplicationProblemJSON),
+		string(ContentTypeApplicationJSON),
+		string(ContentTypeTextPlain),
+	})
+
+	if contentType != string(ContentTypeTextPlain) && contentType != "" {
+		body, err := json.Marshal(r.Problem())
+		if err == nil {
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(r.StatusCode)
+			w.Write(body)
+
+			return nil
+		}
+	}
+
+	message := r.PublicMessage
+	if r.PublicDetail != "" {
+		message = fmt.Sprintf("%s: %s", message, r.PublicDetail)
+	}
+
+	w.Header().Set("Content-Type", string(ContentTypeTextPlain)+"; charset=utf-8")
 	w.WriteHeader(r.StatusCode)
-	fmt.Fprintf(w, r.PublicMessage)
+	fmt.Fprint(w, message)
 
 	return nil
 }
@@ -79,3 +210,66 @@ func Forbidden(request *http.Request, detailedMessage string, args ...interface{
 		DetailedError: fmt.Errorf(detailedMessage, args...),
 	}
 }
+
+func MethodNotAllowed(request *http.Request, detailedMessage string, args ...interface{}) *ResponseError {
+	return &ResponseError{
+		Request:       request,
+		StatusCode:    http.StatusMethodNotAllowed,
+		PublicMessage: "Method not allowed",
+		DetailedError: fmt.Errorf(detailedMessage, args...),
+	}
+}
+
+func NotAcceptable(request *http.Request, detailedMessage string, args ...interface{}) *ResponseError {
+	return &ResponseError{
+		Request:       request,
+		StatusCode:    http.StatusNotAcceptable,
+		PublicMessage: "Not acceptable",
+		DetailedError: fmt.Errorf(detailedMessage, args...),
+	}
+}
+
+func Conflict(request *http.Request, detailedMessage string, args ...interface{}) *ResponseError {
+	return &ResponseError{
+		Request:       request,
+		StatusCode:    http.StatusConflict,
+		PublicMessage: "Conflict",
+		DetailedError: fmt.Errorf(detailedMessage, args...),
+	}
+}
+
+func RequestEntityTooLarge(request *http.Request, detailedMessage string, args ...interface{}) *ResponseError {
+	return &ResponseError{
+		Request:       request,
+		StatusCode:    http.StatusRequestEntityTooLarge,
+		PublicMessage: "Request entity too large",
+		DetailedError: fmt.Errorf(detailedMessage, args...),
+	}
+}
+
+func UnprocessableEntity(request *http.Request, detailedMessage string, args ...interface{}) *ResponseError {
+	return &ResponseError{
+		Request:       request,
+		StatusCode:    http.StatusUnprocessableEntity,
+		PublicMessage: "Unprocessable entity",
+		DetailedError: fmt.Errorf(detailedMessage, args...),
+	}
+}
+
+func TooManyRequests(request *http.Request, detailedMessage string, args ...interface{}) *ResponseError {
+	return &ResponseError{
+		Request:       request,
+		StatusCode:    http.StatusTooManyRequests,
+		PublicMessage: "Too many requests",
+		DetailedError: fmt.Errorf(detailedMessage, args...),
+	}
+}
+
+func ServiceUnavailable(request *http.Request, detailedMessage string, args ...interface{}) *ResponseError {
+	return &ResponseError{
+		Request:       request,
+		StatusCode:    http.StatusServiceUnavailable,
+		PublicMessage: "Service unavailable",
+		DetailedError: fmt.Errorf(detailedMessage, args...),
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,3 +66,57 @@ func TestWithHeaders(t *testing.T) {
 	assert.Equal(t, []string{"value1", "value2"}, writter.Header().Values("X-Test"))
 	assert.Equal(t, []byte("{}"), writter.Body.Bytes())
 }
+
+func TestResponseErrorProblem(t *testing.T) {
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req = req.WithContext(logger.WithContext(req.Context(), logger.Fields{LogFieldRequestID: "req-1"}))
+
+	resp := Conflict(req, "User %d already exists", 1).
+		WithPublicDetail("User already exists").
+		WithExtension("user_id", 1)
+
+	writter := httptest.NewRecorder()
+
+	errResp := resp.Write(writter)
+
+	assert.Nil(t, errResp)
+	assert.Equal(t, http.StatusConflict, writter.Result().StatusCode)
+	assert.Equal(t, "application/problem+json", writter.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Conflict",
+		"status": 409,
+		"detail": "User already exists",
+		"instance": "/users/1",
+		"request_id": "req-1",
+		"user_id": 1
+	}`, writter.Body.String())
+}
+
+func TestResponseErrorText(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Accept", "text/plain, text/html;q=0.9")
+
+	resp := TooManyRequests(req, "Rate limit of %d%% exceeded", 100).
+		WithHeader("Retry-After", "10")
+
+	writter := httptest.NewRecorder()
+
+	errResp := resp.Write(writter)
+
+	assert.Nil(t, errResp)
+	assert.Equal(t, http.StatusTooManyRequests, writter.Result().StatusCode)
+	assert.Equal(t, "text/plain; charset=utf-8", writter.Header().Get("Content-Type"))
+	assert.Equal(t, "10", writter.Header().Get("Retry-After"))
+	assert.Equal(t, "Too many requests", writter.Body.String())
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	offers := []string{"application/problem+json", "application/json", "text/plain"}
+
+	assert.Equal(t, "application/problem+json", negotiateContentType("", offers))
+	assert.Equal(t, "application/problem+json", negotiateContentType("text/html,*/*;q=0.8", offers))
+	assert.Equal(t, "application/json", negotiateContentType("application/json", offers))
+	assert.Equal(t, "text/plain", negotiateContentType("text/*, application/json;q=0.5", offers))
+	assert.Equal(t, "", negotiateContentType("image/png", offers))
+}
@@ -0,0 +1,300 @@
+package gousuchi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+)
+
+// ContentTypeTextEventStream is the content type of server-sent events
+const ContentTypeTextEventStream ContentType = "text/event-stream"
+
+// HeaderLastEventID is sent by reconnecting clients with the id of the last received event
+const HeaderLastEventID = "Last-Event-ID"
+
+// DefaultSSEHeartbeat is the default interval of heartbeat comments keeping idle streams open
+const DefaultSSEHeartbeat = 15 * time.Second
+
+// SSEEvent is a server-sent event
+type SSEEvent struct {
+	// ID is sent back by reconnecting clients as Last-Event-ID (optional)
+	ID string
+	// Event is the name of the event, defaults to "message" in browsers (optional)
+	Event string
+	// Data is sent as is for string and []byte, all other values are encoded as json
+	Data interface{}
+	// Retry tells the client how long to wait before reconnecting (optional)
+	Retry time.Duration
+}
+
+var sseFieldReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// sseLineReplacer normalizes all line terminators of EventSource (CRLF, LF and CR) to LF
+var sseLineReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// encode returns the event in the text/event-stream format
+func (e *SSEEvent) encode() ([]byte, error) {
+	var data []byte
+
+	switch typedData := e.Data.(type) {
+	case nil:
+	case string:
+		data = []byte(typedData)
+	case []byte:
+		data = typedData
+	default:
+		var err error
+
+		data, err = json.Marshal(typedData)
+		if err != nil {
+			return nil, fmt.Errorf("can't encode data of event: %w", err)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+
+	if e.ID != "" {
+		fmt.Fprintf(buf, "id: %s\n", sseFieldReplacer.Replace(e.ID))
+	}
+
+	if e.Event != "" {
+		fmt.Fprintf(buf, "event: %s\n", sseFieldReplacer.Replace(e.Event))
+	}
+
+	if e.Retry > 0 {
+		fmt.Fprintf(buf, "retry: %d\n", e.Retry.Milliseconds())
+	}
+
+	lines := strings.Split(sseLineReplacer.Replace(string(data)), "\n")
+	for _, line := range lines {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+// GetLastEventID returns the id of the last event received by a reconnecting client,
+// the stream should continue after this event
+func GetLastEventID(r *http.Request) string {
+	return r.Header.Get(HeaderLastEventID)
+}
+
+// SSEResponse streams server-sent events from a channel
+//
+// The stream ends when the channel is closed, the client disconnects or the done
+// channel (see WithDone) is closed.
+type SSEResponse struct {
+	Request   *http.Request
+	Header    http.Header
+	Events    <-chan *SSEEvent
+	Heartbeat time.Duration
+	Retry     time.Duration
+	done      <-chan struct{}
+	sent      int
+	reason    string
+	err       error
+}
+
+var _ IResponse = (*SSEResponse)(nil)
+
+func (r *SSEResponse) GetRequest() *http.Request {
+	return r.Request
+}
+
+// WithHeader adds a header to the response
+func (r *SSEResponse) WithHeader(key string, value string) *SSEResponse {
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+
+	r.Header.Add(key, value)
+
+	return r
+}
+
+// WithHeartbeat sets the interval of heartbeat comments (defaults to DefaultSSEHeartbeat),
+// heartbeats are disabled for intervals <= 0
+func (r *SSEResponse) WithHeartbeat(heartbeat time.Duration) *SSEResponse {
+	r.Heartbeat = heartbeat
+
+	return r
+}
+
+// WithRetry tells the client how long to wait before reconnecting
+func (r *SSEResponse) WithRetry(retry time.Duration) *SSEResponse {
+	r.Retry = retry
+
+	return r
+}
+
+// WithDone ends the stream when the channel is closed (e.g. AbstractController.Done)
+func (r *SSEResponse) WithDone(done <-chan struct{}) *SSEResponse {
+	r.done = done
+
+	return r
+}
+
+func (r *SSEResponse) Write(w http.ResponseWriter) IResponse {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return InternalServerError(r.Request, "Can't stream events: response writer does not support flushing")
+	}
+
+	for field, values := range r.Header {
+		w.Header()[field] = values
+	}
+
+	w.Header().Set("Content-Type", string(ContentTypeTextEventStream))
+	w.Header().Set("Cache-Control", "no-cache")
+	// Disables response buffering in nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if r.Retry > 0 {
+		fmt.Fprintf(w, "retry: %d\n\n", r.Retry.Milliseconds())
+	}
+
+	flusher.Flush()
+
+	var heartbeat <-chan time.Time
+
+	if r.Heartbeat > 0 {
+		ticker := time.NewTicker(r.Heartbeat)
+		defer ticker.Stop()
+
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Request.Context().Done():
+			r.reason = "client disconnected"
+
+			return nil
+		case <-r.done:
+			r.reason = "server stopped"
+
+			return nil
+		case event, ok := <-r.Events:
+			if !ok {
+				r.reason = "stream ended"
+
+				return nil
+			}
+
+			if event == nil {
+				continue
+			}
+
+			data, err := event.encode()
+			if err != nil {
+				r.reason = "encoding failed"
+				r.err = err
+
+				return nil
+			}
+
+			_, err = w.Write(data)
+			if err != nil {
+				r.reason = "client disconnected"
+
+				return nil
+			}
+
+			flusher.Flush()
+
+			r.sent++
+		case <-heartbeat:
+			_, err := w.Write([]byte(": heartbeat\n\n"))
+			if err != nil {
+				r.reason = "client disconnected"
+
+				return nil
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+func (r *SSEResponse) Log(log *logger.Log) {
+	if r.err != nil {
+		log.Errorf("%s %s - %d SSE stream closed after %d events (%s): %s", r.Request.Method, r.Request.RequestURI, http.StatusOK, r.sent, r.reason, r.err)
+
+		return
+	}
+
+	log.Infof("%s %s - %d SSE stream closed after %d events (%s)", r.Request.Method, r.Request.RequestURI, http.StatusOK, r.sent, r.reason)
+}
+
+// NewSSEResponse creates a response streaming the events from the channel
+// until it is closed
+//
+// The stream should also be bound to the controller's lifetime via WithDone
+// (see AbstractController.SSE).
+func NewSSEResponse(request *http.Request, events <-chan *SSEEvent) *SSEResponse {
+	return &SSEResponse{
+		Request:   request,
+		Events:    events,
+		Heartbeat: DefaultSSEHeartbeat,
+	}
+}
+
+// SSE creates a response streaming the events from the channel, the stream is closed
+// when the controller is stopped
+//
+// e.g. streaming a broadcaster subscription:
+//
+//	values := b.SubscribeContext(r.Context(), broadcaster.WithPolicy(broadcaster.DeliveryPolicyDropOldest))
+//
+//	return c.SSE(r, gousuchi.SSEChannel(r.Context(), values, func(value int) *gousuchi.SSEEvent {
+//		return &gousuchi.SSEEvent{Event: "value", Data: value}
+//	}))
+func (c *AbstractController) SSE(request *http.Request, events <-chan *SSEEvent) *SSEResponse {
+	return NewSSEResponse(request, events).WithDone(c.Done())
+}
+
+// SSEChannel converts the values of a channel (e.g. a broadcaster subscription) to events,
+// the returned channel is closed when the source channel is closed or the context is
+// cancelled (should be the request's context)
+//
+// Values converted to nil are skipped.
+func SSEChannel[T any](ctx context.Context, values <-chan T, toEvent func(value T) *SSEEvent) <-chan *SSEEvent {
+	events := make(chan *SSEEvent)
+
+	go func() {
+		defer close(events)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case value, ok := <-values:
+				if !ok {
+					return
+				}
+
+				event := toEvent(value)
+				if event == nil {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events
+}
@@ -0,0 +1,135 @@
+package gousuchi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEResponse(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	events := make(chan *SSEEvent, 3)
+	events <- &SSEEvent{ID: "1", Event: "greeting", Data: "hello\nworld"}
+	events <- &SSEEvent{ID: "2\n", Data: map[string]int{"value": 2}, Retry: 5 * time.Second}
+	close(events)
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set(HeaderLastEventID, "0")
+
+	lastEventID := ""
+
+	writer := httptest.NewRecorder()
+	c.Wrap(func(w http.ResponseWriter, r *http.Request) IResponse {
+		lastEventID = GetLastEventID(r)
+
+		return c.SSE(r, events).WithRetry(time.Second)
+	})(writer, req)
+
+	assert.Equal(t, "0", lastEventID)
+	assert.Equal(t, http.StatusOK, writer.Code)
+	assert.Equal(t, "text/event-stream", writer.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", writer.Header().Get("Cache-Control"))
+	assert.True(t, writer.Flushed)
+	assert.Equal(
+		t,
+		"retry: 1000\n\n"+
+			"id: 1\nevent: greeting\ndata: hello\ndata: world\n\n"+
+			"id: 2\nretry: 5000\ndata: {\"value\":2}\n\n",
+		writer.Body.String(),
+	)
+}
+
+func TestSSEEventEncode(t *testing.T) {
+	// All line terminators are split, so data can't inject fields
+	data, err := (&SSEEvent{Data: "x\revent: admin\rid: 999\r\ny\nz"}).encode()
+	require.NoError(t, err)
+	assert.Equal(t, "data: x\ndata: event: admin\ndata: id: 999\ndata: y\ndata: z\n\n", string(data))
+
+	data, err = (&SSEEvent{Data: []byte("a\rretry: 1")}).encode()
+	require.NoError(t, err)
+	assert.Equal(t, "data: a\ndata: retry: 1\n\n", string(data))
+}
+
+func TestSSEResponseHeartbeat(t *testing.T) {
+	events := make(chan *SSEEvent)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+
+	go func() {
+		time.Sleep(35 * time.Millisecond)
+		cancel()
+	}()
+
+	writer := httptest.NewRecorder()
+	resp := NewSSEResponse(req, events).WithHeartbeat(10 * time.Millisecond)
+	assert.Nil(t, resp.Write(writer))
+
+	assert.Contains(t, writer.Body.String(), ": heartbeat\n\n")
+	assert.Equal(t, "client disconnected", resp.reason)
+}
+
+func TestSSEResponseStop(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+
+	finished := make(chan struct{})
+
+	resp := c.SSE(httptest.NewRequest(http.MethodGet, "/events", nil), make(chan *SSEEvent))
+
+	go func() {
+		defer close(finished)
+
+		resp.Write(httptest.NewRecorder())
+	}()
+
+	require.NoError(t, c.Stop())
+	require.NoError(t, c.Stop())
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("stream was not closed on stop")
+	}
+
+	assert.Equal(t, "server stopped", resp.reason)
+}
+
+func TestSSEChannel(t *testing.T) {
+	values := make(chan int, 3)
+	values <- 1
+	values <- 2
+	values <- 3
+	close(values)
+
+	events := SSEChannel(context.Background(), values, func(value int) *SSEEvent {
+		if value == 2 {
+			return nil
+		}
+
+		return &SSEEvent{Data: value}
+	})
+
+	data := []interface{}{}
+	for event := range events {
+		data = append(data, event.Data)
+	}
+
+	assert.Equal(t, []interface{}{1, 3}, data)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events = SSEChannel(ctx, make(chan int), func(value int) *SSEEvent {
+		return &SSEEvent{Data: value}
+	})
+
+	cancel()
+
+	_, ok := <-events
+	assert.False(t, ok)
+}
@@ -0,0 +1,406 @@
+package gousuchi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Default limits of ParseUpload
+const (
+	DefaultUploadMaxFileSize  = 10 << 20
+	DefaultUploadMaxTotalSize = 32 << 20
+	DefaultUploadMaxFiles     = 10
+	DefaultUploadMaxValueSize = 1 << 20
+	DefaultUploadMaxParts     = 100
+)
+
+// ValidationRuleMimeType is used for uploaded files with a content type not in the allowlist
+const ValidationRuleMimeType = "mimeType"
+
+// uploadSniffLength is the number of bytes used by http.DetectContentType
+const uploadSniffLength = 512
+
+// uploadPartOverhead is the size allowed per part for boundaries and headers on top
+// of MaxTotalSize
+const uploadPartOverhead = 4 << 10
+
+var (
+	errUploadFileTooLarge  = errors.New("file too large")
+	errUploadTotalTooLarge = errors.New("upload too large")
+)
+
+// UploadOptions configures the limits of ParseUpload, zero values use the defaults
+type UploadOptions struct {
+	// MaxFileSize is the maximum size of a single file (defaults to DefaultUploadMaxFileSize)
+	MaxFileSize int64
+	// MaxTotalSize is the maximum size of all files and values (defaults to DefaultUploadMaxTotalSize)
+	MaxTotalSize int64
+	// MaxFiles is the maximum number of files (defaults to DefaultUploadMaxFiles)
+	MaxFiles int
+	// MaxValueSize is the maximum size of a single non-file value (defaults to DefaultUploadMaxValueSize)
+	MaxValueSize int64
+	// MaxParts is the maximum number of parts including files, values and ignored
+	// parts (defaults to DefaultUploadMaxParts)
+	MaxParts int
+	// AllowedMimeTypes is the allowlist of sniffed content types, supports wildcards
+	// like "image/*" (all types are allowed if empty)
+	AllowedMimeTypes []string
+}
+
+func (o *UploadOptions) withDefaults() *UploadOptions {
+	options := UploadOptions{}
+	if o != nil {
+		options = *o
+	}
+
+	if options.MaxFileSize <= 0 {
+		options.MaxFileSize = DefaultUploadMaxFileSize
+	}
+
+	if options.MaxTotalSize <= 0 {
+		options.MaxTotalSize = DefaultUploadMaxTotalSize
+	}
+
+	if options.MaxFiles <= 0 {
+		options.MaxFiles = DefaultUploadMaxFiles
+	}
+
+	if options.MaxValueSize <= 0 {
+		options.MaxValueSize = DefaultUploadMaxValueSize
+	}
+
+	if options.MaxParts <= 0 {
+		options.MaxParts = DefaultUploadMaxParts
+	}
+
+	return &options
+}
+
+// allowsMimeType checks if the content type matches the allowlist
+func (o *UploadOptions) allowsMimeType(contentType string) bool {
+	if len(o.AllowedMimeTypes) == 0 {
+		return true
+	}
+
+	for _, allowed := range o.AllowedMimeTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+
+		if allowed == "*/*" || allowed == contentType {
+			return true
+		}
+
+		prefix, ok := strings.CutSuffix(allowed, "/*")
+		if ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UploadedFile describes a file of a multipart upload
+type UploadedFile struct {
+	// Field is the name of the form field
+	Field string
+	// Filename is the base name of the file sent by the client, it must not be
+	// trusted for building paths
+	Filename string
+	// ContentType is the sniffed content type (see http.DetectContentType), the
+	// content type sent by the client is ignored
+	ContentType string
+	// Size is the size of the file in bytes
+	Size int64
+	// Path is the location of the stored file (only set by ParseUploadToDir)
+	Path string
+}
+
+// UploadHandler processes the content of an uploaded file, it can set file.Path if the
+// file is stored
+//
+// Reading beyond the limits fails, the remaining content is discarded after the handler
+// returns.
+type UploadHandler func(file *UploadedFile, reader io.Reader) error
+
+// Upload is the result of ParseUpload
+type Upload struct {
+	// Files contains all uploaded files in the order of the request
+	Files []*UploadedFile
+	// Values contains the non-file form values
+	Values url.Values
+}
+
+// File returns the first file of a field or nil
+func (u *Upload) File(field string) *UploadedFile {
+	for _, file := range u.Files {
+		if file.Field == field {
+			return file
+		}
+	}
+
+	return nil
+}
+
+// Cleanup removes all stored files (see UploadedFile.Path)
+func (u *Upload) Cleanup() error {
+	var errs []error
+
+	for _, file := range u.Files {
+		if file.Path == "" {
+			continue
+		}
+
+		err := os.Remove(file.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// uploadReader enforces the file and total size limits while reading a part
+type uploadReader struct {
+	reader       io.Reader
+	fileSize     int64
+	maxFileSize  int64
+	totalSize    *int64
+	maxTotalSize int64
+	err          error
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.reader.Read(p)
+
+	r.fileSize += int64(n)
+	*r.totalSize += int64(n)
+
+	switch {
+	case r.fileSize > r.maxFileSize:
+		r.err = errUploadFileTooLarge
+	case *r.totalSize > r.maxTotalSize:
+		r.err = errUploadTotalTooLarge
+	case err != nil && err != io.EOF:
+		r.err = err
+	}
+
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	return n, err
+}
+
+// uploadReadError converts errors of reading the request body into responses
+func uploadReadError(request *http.Request, field string, options *UploadOptions, err error) IResponse {
+	var maxBytesError *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, errUploadFileTooLarge):
+		return RequestEntityTooLarge(request, "File in field %s exceeds limit of %d bytes", field, options.MaxFileSize)
+	case errors.Is(err, errUploadTotalTooLarge):
+		return RequestEntityTooLarge(request, "Upload exceeds limit of %d bytes", options.MaxTotalSize)
+	case errors.As(err, &maxBytesError):
+		return RequestEntityTooLarge(request, "Request body exceeds limit of %d bytes", maxBytesError.Limit)
+	default:
+		return BadRequest(request, "Can't read multipart body: %s", err)
+	}
+}
+
+// ParseUpload streams the parts of a multipart/form-data request
+//
+// Each file is passed to the handler without buffering it in memory, its content type
+// is sniffed from the first 512 bytes and checked against the allowlist. Non-file values
+// are collected in Upload.Values.
+//
+// Returns a BadRequest-Response for invalid requests and disallowed content types and a
+// RequestEntityTooLarge-Response if a limit is exceeded. Errors returned by the handler
+// result in an InternalServerError-Response. The upload is also returned on errors, so
+// files already stored by the handler can be removed via Upload.Cleanup.
+func ParseUpload(request *http.Request, options *UploadOptions, handler UploadHandler) (*Upload, IResponse) {
+	options = options.withDefaults()
+
+	// Limits the whole body, as skipped and closed parts are discarded without
+	// passing the limits below
+	request.Body = http.MaxBytesReader(nil, request.Body, options.MaxTotalSize+int64(options.MaxParts)*uploadPartOverhead)
+
+	reader, err := request.MultipartReader()
+	if err != nil {
+		return nil, BadRequest(request, "Invalid multipart request: %s", err)
+	}
+
+	upload := &Upload{
+		Files:  []*UploadedFile{},
+		Values: url.Values{},
+	}
+
+	totalSize := int64(0)
+	parts := 0
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return upload, uploadReadError(request, "", options, err)
+		}
+
+		parts++
+		if parts > options.MaxParts {
+			part.Close()
+
+			return upload, BadRequest(request, "Upload exceeds limit of %d parts", options.MaxParts)
+		}
+
+		field := part.FormName()
+		if field == "" {
+			part.Close()
+
+			continue
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, options.MaxValueSize+1))
+			part.Close()
+			if err != nil {
+				return upload, uploadReadError(request, field, options, err)
+			}
+
+			if int64(len(value)) > options.MaxValueSize {
+				return upload, RequestEntityTooLarge(request, "Value of field %s exceeds limit of %d bytes", field, options.MaxValueSize)
+			}
+
+			totalSize += int64(len(value))
+			if totalSize > options.MaxTotalSize {
+				return upload, RequestEntityTooLarge(request, "Upload exceeds limit of %d bytes", options.MaxTotalSize)
+			}
+
+			upload.Values.Add(field, string(value))
+
+			continue
+		}
+
+		if len(upload.Files) >= options.MaxFiles {
+			part.Close()
+
+			return upload, BadRequest(request, "Upload exceeds limit of %d files", options.MaxFiles).
+				WithFieldErrors([]*FieldError{
+					{
+						Field:   field,
+						Source:  FieldSourceForm,
+						Rule:    ValidationRuleMax,
+						Message: fmt.Sprintf("must have at most %d files", options.MaxFiles),
+					},
+				})
+		}
+
+		partReader := &uploadReader{
+			reader:       part,
+			maxFileSize:  options.MaxFileSize,
+			totalSize:    &totalSize,
+			maxTotalSize: options.MaxTotalSize,
+		}
+
+		head := make([]byte, uploadSniffLength)
+
+		n, err := io.ReadFull(partReader, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			part.Close()
+
+			return upload, uploadReadError(request, field, options, err)
+		}
+
+		head = head[:n]
+
+		contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+
+		if !options.allowsMimeType(contentType) {
+			part.Close()
+
+			return upload, BadRequest(request, "File in field %s has disallowed content type %s", field, contentType).
+				WithFieldErrors([]*FieldError{
+					{
+						Field:   field,
+						Source:  FieldSourceForm,
+						Rule:    ValidationRuleMimeType,
+						Message: fmt.Sprintf("content type %s is not allowed", contentType),
+					},
+				})
+		}
+
+		file := &UploadedFile{
+			Field:       field,
+			Filename:    part.FileName(),
+			ContentType: contentType,
+		}
+
+		upload.Files = append(upload.Files, file)
+
+		content := io.MultiReader(bytes.NewReader(head), partReader)
+
+		err = handler(file, content)
+		if err == nil {
+			// Enforces the limits on content not read by the handler
+			_, err = io.Copy(io.Discard, content)
+		}
+
+		part.Close()
+
+		file.Size = partReader.fileSize
+
+		if partReader.err != nil {
+			return upload, uploadReadError(request, field, options, partReader.err)
+		}
+
+		if err != nil {
+			return upload, InternalServerError(request, "Can't process file in field %s: %s", field, err)
+		}
+	}
+
+	return upload, nil
+}
+
+// ParseUploadToDir streams all files of a multipart/form-data request to temporary
+// files in dir (see ParseUpload)
+//
+// The stored files are removed if the upload fails, else the caller must move or remove
+// them (see Upload.Cleanup).
+func ParseUploadToDir(request *http.Request, dir string, options *UploadOptions) (*Upload, IResponse) {
+	upload, resp := ParseUpload(request, options, func(file *UploadedFile, reader io.Reader) error {
+		out, err := os.CreateTemp(dir, "upload-*")
+		if err != nil {
+			return fmt.Errorf("can't create file: %w", err)
+		}
+
+		file.Path = out.Name()
+
+		_, err = io.Copy(out, reader)
+		if err != nil {
+			out.Close()
+
+			return err
+		}
+
+		return out.Close()
+	})
+	if resp != nil {
+		if upload != nil {
+			upload.Cleanup()
+		}
+
+		return nil, resp
+	}
+
+	return upload, nil
+}
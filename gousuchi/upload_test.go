@@ -0,0 +1,206 @@
+package gousuchi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testUploadPNG = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+
+type testUploadFile struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+func newTestUploadRequest(t *testing.T, values map[string]string, files []*testUploadFile) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for field, value := range values {
+		require.NoError(t, writer.WriteField(field, value))
+	}
+
+	for _, file := range files {
+		part, err := writer.CreateFormFile(file.field, file.filename)
+		require.NoError(t, err)
+
+		_, err = part.Write(file.content)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return req
+}
+
+func getTestUploadStatusCode(resp IResponse) int {
+	responseError, ok := resp.(*ResponseError)
+	if !ok {
+		return 0
+	}
+
+	return responseError.StatusCode
+}
+
+func TestParseUpload(t *testing.T) {
+	req := newTestUploadRequest(t, map[string]string{"title": "Test"}, []*testUploadFile{
+		{field: "image", filename: "image.png", content: testUploadPNG},
+		{field: "notes", filename: "../notes.txt", content: []byte("hello world")},
+	})
+
+	contents := map[string]string{}
+
+	upload, resp := ParseUpload(req, &UploadOptions{
+		AllowedMimeTypes: []string{"image/*", "text/plain"},
+	}, func(file *UploadedFile, reader io.Reader) error {
+		data, err := io.ReadAll(reader)
+		contents[file.Field] = string(data)
+
+		return err
+	})
+	require.Nil(t, resp)
+
+	assert.Equal(t, "Test", upload.Values.Get("title"))
+	require.Len(t, upload.Files, 2)
+
+	image := upload.File("image")
+	require.NotNil(t, image)
+	assert.Equal(t, "image.png", image.Filename)
+	assert.Equal(t, "image/png", image.ContentType)
+	assert.Equal(t, int64(len(testUploadPNG)), image.Size)
+	assert.Equal(t, string(testUploadPNG), contents["image"])
+
+	notes := upload.File("notes")
+	require.NotNil(t, notes)
+	assert.Equal(t, "notes.txt", notes.Filename)
+	assert.Equal(t, "text/plain", notes.ContentType)
+	assert.Equal(t, "hello world", contents["notes"])
+
+	assert.Nil(t, upload.File("missing"))
+}
+
+func TestParseUploadLimits(t *testing.T) {
+	ignore := func(file *UploadedFile, reader io.Reader) error {
+		return nil
+	}
+
+	req := newTestUploadRequest(t, nil, []*testUploadFile{
+		{field: "file", filename: "large.txt", content: []byte(strings.Repeat("a", 1000))},
+	})
+
+	_, resp := ParseUpload(req, &UploadOptions{MaxFileSize: 600}, ignore)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, getTestUploadStatusCode(resp))
+
+	req = newTestUploadRequest(t, nil, []*testUploadFile{
+		{field: "a", filename: "a.txt", content: []byte(strings.Repeat("a", 400))},
+		{field: "b", filename: "b.txt", content: []byte(strings.Repeat("b", 400))},
+	})
+
+	_, resp = ParseUpload(req, &UploadOptions{MaxTotalSize: 600}, ignore)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, getTestUploadStatusCode(resp))
+
+	req = newTestUploadRequest(t, nil, []*testUploadFile{
+		{field: "a", filename: "a.txt", content: []byte("a")},
+		{field: "b", filename: "b.txt", content: []byte("b")},
+	})
+
+	_, resp = ParseUpload(req, &UploadOptions{MaxFiles: 1}, ignore)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusBadRequest, getTestUploadStatusCode(resp))
+
+	req = newTestUploadRequest(t, nil, []*testUploadFile{
+		{field: "image", filename: "image.png", content: []byte("<html><body>fake</body></html>")},
+	})
+
+	_, resp = ParseUpload(req, &UploadOptions{AllowedMimeTypes: []string{"image/png"}}, ignore)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusBadRequest, getTestUploadStatusCode(resp))
+	assert.Equal(t, ValidationRuleMimeType, resp.(*ResponseError).FieldErrors[0].Rule)
+
+	// Parts without name are discarded but still limited
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{})
+	require.NoError(t, err)
+
+	_, err = part.Write(bytes.Repeat([]byte("a"), 100000))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req = httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	_, resp = ParseUpload(req, &UploadOptions{MaxTotalSize: 600, MaxParts: 1}, ignore)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, getTestUploadStatusCode(resp))
+
+	values := map[string]string{}
+	for i := 0; i < 3; i++ {
+		values[fmt.Sprintf("value%d", i)] = "a"
+	}
+
+	req = newTestUploadRequest(t, values, nil)
+
+	_, resp = ParseUpload(req, &UploadOptions{MaxParts: 2}, ignore)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusBadRequest, getTestUploadStatusCode(resp))
+
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+
+	_, resp = ParseUpload(req, nil, ignore)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusBadRequest, getTestUploadStatusCode(resp))
+}
+
+func TestParseUploadToDir(t *testing.T) {
+	dir := t.TempDir()
+
+	req := newTestUploadRequest(t, nil, []*testUploadFile{
+		{field: "image", filename: "image.png", content: testUploadPNG},
+	})
+
+	upload, resp := ParseUploadToDir(req, dir, nil)
+	require.Nil(t, resp)
+	require.Len(t, upload.Files, 1)
+
+	data, err := os.ReadFile(upload.Files[0].Path)
+	require.NoError(t, err)
+	assert.Equal(t, testUploadPNG, data)
+
+	require.NoError(t, upload.Cleanup())
+
+	_, err = os.Stat(upload.Files[0].Path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Stored files are removed if a later file fails
+	req = newTestUploadRequest(t, nil, []*testUploadFile{
+		{field: "a", filename: "a.png", content: testUploadPNG},
+		{field: "b", filename: "b.txt", content: []byte(strings.Repeat("b", 1000))},
+	})
+
+	_, resp = ParseUploadToDir(req, dir, &UploadOptions{MaxFileSize: 600})
+	require.NotNil(t, resp)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
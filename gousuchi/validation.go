@@ -0,0 +1,374 @@
+package gousuchi
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Sources of a FieldError
+const (
+	FieldSourceBody  = "body"
+	FieldSourceForm  = "form"
+	FieldSourceQuery = "query"
+	FieldSourceURL   = "url"
+)
+
+// Rules of a FieldError
+const (
+	ValidationRuleRequired = "required"
+	ValidationRuleMin      = "min"
+	ValidationRuleMax      = "max"
+	ValidationRuleLen      = "len"
+	ValidationRuleRegex    = "regex"
+	ValidationRuleEnum     = "enum"
+	// ValidationRuleType is used for values which can't be parsed into the field's type
+	ValidationRuleType = "type"
+)
+
+// FieldError describes an invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Source  string `json:"source,omitempty"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+var validationRegexps sync.Map
+
+func getValidationRegexp(pattern string) (*regexp.Regexp, error) {
+	cached, ok := validationRegexps.Load(pattern)
+	if ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	validationRegexps.Store(pattern, compiled)
+
+	return compiled, nil
+}
+
+// unwrapValue dereferences pointers and nullable types (e.g. null.String),
+// ok is false if no value is set
+func unwrapValue(value reflect.Value) (reflect.Value, bool) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return value, false
+		}
+
+		value = value.Elem()
+	}
+
+	if valuer, ok := value.Interface().(driver.Valuer); ok {
+		inner, err := valuer.Value()
+		if err != nil || inner == nil {
+			return value, false
+		}
+
+		return reflect.ValueOf(inner), true
+	}
+
+	return value, true
+}
+
+func numericValue(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func lengthValue(value reflect.Value) (int, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), true
+	default:
+		return 0, false
+	}
+}
+
+// splitRules splits a validate tag into its rules, the regex rule must be
+// the last rule as its pattern can contain commas
+func splitRules(tag string) []string {
+	rules := []string{}
+
+	for tag != "" {
+		if strings.HasPrefix(tag, ValidationRuleRegex+"=") {
+			return append(rules, tag)
+		}
+
+		rule, rest, _ := strings.Cut(tag, ",")
+		rules = append(rules, strings.TrimSpace(rule))
+		tag = rest
+	}
+
+	return rules
+}
+
+func validateRule(value reflect.Value, rule string, param string) (string, error) {
+	switch rule {
+	case ValidationRuleMin, ValidationRuleMax:
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid parameter for rule %s: %s", rule, err)
+		}
+
+		if number, ok := numericValue(value); ok {
+			if rule == ValidationRuleMin && number < limit {
+				return fmt.Sprintf("must be at least %s", param), nil
+			}
+
+			if rule == ValidationRuleMax && number > limit {
+				return fmt.Sprintf("must be at most %s", param), nil
+			}
+
+			return "", nil
+		}
+
+		if length, ok := lengthValue(value); ok {
+			if rule == ValidationRuleMin && float64(length) < limit {
+				return fmt.Sprintf("must have a length of at least %s", param), nil
+			}
+
+			if rule == ValidationRuleMax && float64(length) > limit {
+				return fmt.Sprintf("must have a length of at most %s", param), nil
+			}
+
+			return "", nil
+		}
+
+		return "", fmt.Errorf("rule %s not supported for type %s", rule, value.Type())
+	case ValidationRuleLen:
+		expected, err := strconv.Atoi(param)
+		if err != nil {
+			return "", fmt.Errorf("invalid parameter for rule %s: %s", rule, err)
+		}
+
+		length, ok := lengthValue(value)
+		if !ok {
+			return "", fmt.Errorf("rule %s not supported for type %s", rule, value.Type())
+		}
+
+		if length != expected {
+			return fmt.Sprintf("must have a length of %d", expected), nil
+		}
+
+		return "", nil
+	case ValidationRuleRegex:
+		regexpValue, err := getValidationRegexp(param)
+		if err != nil {
+			return "", fmt.Errorf("invalid parameter for rule %s: %s", rule, err)
+		}
+
+		if !regexpValue.MatchString(fmt.Sprint(value.Interface())) {
+			return fmt.Sprintf("must match %s", param), nil
+		}
+
+		return "", nil
+	case ValidationRuleEnum:
+		allowed := strings.Split(param, "|")
+		actual := fmt.Sprint(value.Interface())
+
+		for _, allowedValue := range allowed {
+			if actual == allowedValue {
+				return "", nil
+			}
+		}
+
+		return fmt.Sprintf("must be one of %s", strings.Join(allowed, ", ")), nil
+	default:
+		return "", fmt.Errorf("unknown validation rule %s", rule)
+	}
+}
+
+// validateField checks all rules of a validate tag against a value
+//
+// Only the first failed rule is reported
+func validateField(value reflect.Value, tag string, field string, source string) (*FieldError, error) {
+	unwrapped, ok := unwrapValue(value)
+
+	for _, rule := range splitRules(tag) {
+		rule, param, _ := strings.Cut(rule, "=")
+
+		if rule == ValidationRuleRequired {
+			if !ok || unwrapped.IsZero() {
+				return &FieldError{
+					Field:   field,
+					Source:  source,
+					Rule:    rule,
+					Message: "is required",
+				}, nil
+			}
+
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		message, err := validateRule(unwrapped, rule, param)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validation of field %s: %s", field, err)
+		}
+
+		if message != "" {
+			return &FieldError{
+				Field:   field,
+				Source:  source,
+				Rule:    rule,
+				Message: message,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// fieldName returns the name of a field in the request and its source
+func fieldName(field reflect.StructField) (string, string) {
+	for _, source := range []string{FieldSourceURL, FieldSourceQuery, FieldSourceForm} {
+		name, _, _ := strings.Cut(field.Tag.Get(source), ",")
+		if name != "" && name != "-" {
+			return name, source
+		}
+	}
+
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name != "" && name != "-" {
+		return name, FieldSourceBody
+	}
+
+	return field.Name, FieldSourceBody
+}
+
+var typeTime = reflect.TypeOf(time.Time{})
+
+var typeValuer = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+func validateStruct(value reflect.Value, prefix string, fieldErrors []*FieldError) ([]*FieldError, error) {
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		structField := valueType.Field(i)
+
+		// Fields of embedded structs are validated as fields of the struct
+		jsonName, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+		if structField.Anonymous && jsonName == "" {
+			embedded := value.Field(i)
+			for embedded.Kind() == reflect.Pointer && !embedded.IsNil() {
+				embedded = embedded.Elem()
+			}
+
+			isValuer := reflect.PointerTo(embedded.Type()).Implements(typeValuer)
+			if embedded.Kind() == reflect.Struct && embedded.Type() != typeTime && !isValuer {
+				var err error
+
+				fieldErrors, err = validateStruct(embedded, prefix, fieldErrors)
+				if err != nil {
+					return nil, err
+				}
+
+				continue
+			}
+		}
+
+		if !structField.IsExported() {
+			continue
+		}
+
+		name, source := fieldName(structField)
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		fieldValue := value.Field(i)
+
+		tag := structField.Tag.Get("validate")
+		if tag != "" && tag != "-" {
+			fieldError, err := validateField(fieldValue, tag, name, source)
+			if err != nil {
+				return nil, err
+			}
+
+			if fieldError != nil {
+				fieldErrors = append(fieldErrors, fieldError)
+
+				continue
+			}
+		}
+
+		// Validate nested structs
+		nested := fieldValue
+		for nested.Kind() == reflect.Pointer && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+
+		if nested.Kind() != reflect.Struct || nested.Type() == typeTime {
+			continue
+		}
+
+		if _, ok := nested.Interface().(driver.Valuer); ok {
+			continue
+		}
+
+		var err error
+
+		fieldErrors, err = validateStruct(nested, name, fieldErrors)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return fieldErrors, nil
+}
+
+// ValidateStruct checks the rules in the validate tags of all fields of a struct
+//
+// Rules are separated by commas, e.g. `validate:"required,min=3,max=20"`:
+//   - required: the value must be set and not be the zero value
+//   - min=n / max=n: numbers must be within the range, strings, slices and maps must have the length
+//   - len=n: strings, slices and maps must have exactly the length
+//   - enum=a|b|c: the value must be one of the listed values
+//   - regex=pattern: the value must match the pattern (must be the last rule)
+//
+// All rules except required are skipped for unset pointers and null values. Nested
+// structs are validated recursively. The returned error is only set for invalid rules.
+func ValidateStruct(target interface{}) ([]*FieldError, error) {
+	value := reflect.ValueOf(target)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil, fmt.Errorf("can't validate nil")
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can't validate %s, expected struct", value.Type())
+	}
+
+	return validateStruct(value, "", []*FieldError{})
+}
@@ -0,0 +1,577 @@
+package gousuchi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+)
+
+// Message types of websockets
+const (
+	WebSocketTextMessage   = websocket.TextMessage
+	WebSocketBinaryMessage = websocket.BinaryMessage
+)
+
+// Defaults of WebSocketOptions
+const (
+	DefaultWebSocketReadLimit     = 64 * 1024
+	DefaultWebSocketSendQueueSize = 64
+	DefaultWebSocketPingInterval  = 30 * time.Second
+	DefaultWebSocketPongTimeout   = 60 * time.Second
+	DefaultWebSocketWriteTimeout  = 10 * time.Second
+)
+
+// Errors returned by WebSocketConn.Send
+var (
+	ErrWebSocketClosed        = errors.New("websocket is closed")
+	ErrWebSocketSendQueueFull = errors.New("send queue of websocket is full")
+)
+
+// WebSocketAuthenticator is called before the upgrade of a websocket, it returns the
+// request to use for the connection (e.g. with claims in its context) or a response
+// rejecting the upgrade (see gousujwt.NewWebSocketAuthenticator)
+type WebSocketAuthenticator func(r *http.Request) (*http.Request, IResponse)
+
+// WebSocketOptions configures AbstractController.WebSocket
+type WebSocketOptions struct {
+	// ReadLimit is the maximum size of a received message in bytes, larger messages
+	// close the connection (defaults to DefaultWebSocketReadLimit)
+	ReadLimit int64
+	// SendQueueSize is the number of messages queued per connection (defaults to
+	// DefaultWebSocketSendQueueSize)
+	SendQueueSize int
+	// PingInterval defaults to DefaultWebSocketPingInterval
+	PingInterval time.Duration
+	// PongTimeout closes connections not answering pings (defaults to DefaultWebSocketPongTimeout)
+	PongTimeout time.Duration
+	// WriteTimeout defaults to DefaultWebSocketWriteTimeout
+	WriteTimeout time.Duration
+	// CheckOrigin defaults to accepting only requests from the same host
+	CheckOrigin func(r *http.Request) bool
+	// Subprotocols are the supported subprotocols in order of preference
+	Subprotocols []string
+	// Authenticate is called before the upgrade (optional)
+	Authenticate WebSocketAuthenticator
+}
+
+func (o *WebSocketOptions) withDefaults() *WebSocketOptions {
+	options := WebSocketOptions{}
+	if o != nil {
+		options = *o
+	}
+
+	if options.ReadLimit <= 0 {
+		options.ReadLimit = DefaultWebSocketReadLimit
+	}
+
+	if options.SendQueueSize <= 0 {
+		options.SendQueueSize = DefaultWebSocketSendQueueSize
+	}
+
+	if options.PingInterval <= 0 {
+		options.PingInterval = DefaultWebSocketPingInterval
+	}
+
+	if options.PongTimeout <= 0 {
+		options.PongTimeout = DefaultWebSocketPongTimeout
+	}
+
+	if options.WriteTimeout <= 0 {
+		options.WriteTimeout = DefaultWebSocketWriteTimeout
+	}
+
+	return &options
+}
+
+// WebSocketHandler contains the callbacks of a websocket connection, all are optional
+type WebSocketHandler struct {
+	// OnConnect is called after the upgrade (e.g. to join groups)
+	OnConnect func(conn *WebSocketConn)
+	// OnMessage is called for each received message, messages of a connection are
+	// handled sequentially
+	OnMessage func(conn *WebSocketConn, messageType int, data []byte)
+	// OnClose is called after the connection was closed
+	OnClose func(conn *WebSocketConn)
+}
+
+type webSocketMessage struct {
+	messageType int
+	data        []byte
+}
+
+// WebSocketConn is an upgraded websocket connection
+type WebSocketConn struct {
+	ID       string
+	Request  *http.Request
+	conn     *websocket.Conn
+	hub      *WebSocketHub
+	options  *WebSocketOptions
+	send     chan *webSocketMessage
+	ctx      context.Context
+	closing  chan struct{}
+	closed   chan struct{}
+	once     sync.Once
+	code     int
+	reason   string
+	received atomic.Int64
+	sent     atomic.Int64
+	readErr  error
+	writeErr error
+}
+
+// Context returns a context which is cancelled when the connection is closed
+func (c *WebSocketConn) Context() context.Context {
+	return c.ctx
+}
+
+// Send queues a message without blocking
+//
+// ErrWebSocketSendQueueFull is returned if the client doesn't keep up with the messages,
+// ErrWebSocketClosed if the connection was closed.
+func (c *WebSocketConn) Send(messageType int, data []byte) error {
+	select {
+	case <-c.closing:
+		return ErrWebSocketClosed
+	default:
+	}
+
+	select {
+	case c.send <- &webSocketMessage{messageType: messageType, data: data}:
+		return nil
+	default:
+		return ErrWebSocketSendQueueFull
+	}
+}
+
+// SendText queues a text message (see Send)
+func (c *WebSocketConn) SendText(text string) error {
+	return c.Send(WebSocketTextMessage, []byte(text))
+}
+
+// SendJSON queues a text message with the json encoded value (see Send)
+func (c *WebSocketConn) SendJSON(value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return c.Send(WebSocketTextMessage, data)
+}
+
+// Join adds the connection to a group of its hub, it is removed automatically when closed
+func (c *WebSocketConn) Join(group string) {
+	if c.hub != nil {
+		c.hub.join(c, group)
+	}
+}
+
+// Leave removes the connection from a group of its hub
+func (c *WebSocketConn) Leave(group string) {
+	if c.hub != nil {
+		c.hub.leave(c, group)
+	}
+}
+
+// Close sends a close message with the code (e.g. websocket.CloseNormalClosure) and
+// reason to the client and closes the connection
+func (c *WebSocketConn) Close(code int, reason string) {
+	c.once.Do(func() {
+		c.code = code
+		c.reason = reason
+
+		close(c.closing)
+	})
+}
+
+func (c *WebSocketConn) write(messageType int, data []byte) error {
+	err := c.conn.SetWriteDeadline(time.Now().Add(c.options.WriteTimeout))
+	if err != nil {
+		return err
+	}
+
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// writeLoop writes queued messages and pings until the connection is closed
+func (c *WebSocketConn) writeLoop(done <-chan struct{}) {
+	defer close(c.closed)
+
+	ticker := time.NewTicker(c.options.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message := <-c.send:
+			err := c.write(message.messageType, message.data)
+			if err != nil {
+				c.writeErr = err
+				c.conn.Close()
+
+				return
+			}
+
+			c.sent.Add(1)
+		case <-ticker.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.options.WriteTimeout))
+			if err != nil {
+				c.writeErr = err
+				c.conn.Close()
+
+				return
+			}
+		case <-done:
+			done = nil
+
+			c.Close(websocket.CloseGoingAway, "server stopped")
+		case <-c.closing:
+			if c.code == websocket.CloseAbnormalClosure {
+				// The connection is broken, a close message can't be sent
+				return
+			}
+
+			c.conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.code, c.reason),
+				time.Now().Add(c.options.WriteTimeout),
+			)
+
+			// Give the client some time to answer the close message
+			c.conn.SetReadDeadline(time.Now().Add(c.options.WriteTimeout))
+
+			return
+		}
+	}
+}
+
+// readLoop reads messages until the connection is closed
+func (c *WebSocketConn) readLoop(onMessage func(conn *WebSocketConn, messageType int, data []byte)) {
+	c.conn.SetReadLimit(c.options.ReadLimit)
+	c.conn.SetReadDeadline(time.Now().Add(c.options.PongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.options.PongTimeout))
+	})
+
+	for {
+		messageType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			closeError := &websocket.CloseError{}
+			if errors.As(err, &closeError) {
+				c.Close(closeError.Code, "")
+			} else if errors.Is(err, websocket.ErrReadLimit) {
+				c.Close(websocket.CloseMessageTooBig, "message too big")
+			} else {
+				c.Close(websocket.CloseAbnormalClosure, "")
+			}
+
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+				c.readErr = err
+			}
+
+			return
+		}
+
+		c.received.Add(1)
+
+		if onMessage != nil {
+			onMessage(c, messageType, data)
+		}
+	}
+}
+
+// WebSocketHub keeps track of websocket connections for broadcasting messages
+type WebSocketHub struct {
+	conns  map[*WebSocketConn]map[string]bool
+	groups map[string]map[*WebSocketConn]bool
+	mutex  sync.RWMutex
+}
+
+func (h *WebSocketHub) add(conn *WebSocketConn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.conns[conn] = map[string]bool{}
+}
+
+func (h *WebSocketHub) remove(conn *WebSocketConn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for group := range h.conns[conn] {
+		delete(h.groups[group], conn)
+
+		if len(h.groups[group]) == 0 {
+			delete(h.groups, group)
+		}
+	}
+
+	delete(h.conns, conn)
+}
+
+func (h *WebSocketHub) join(conn *WebSocketConn, group string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	groups, ok := h.conns[conn]
+	if !ok {
+		return
+	}
+
+	groups[group] = true
+
+	if _, ok := h.groups[group]; !ok {
+		h.groups[group] = map[*WebSocketConn]bool{}
+	}
+
+	h.groups[group][conn] = true
+}
+
+func (h *WebSocketHub) leave(conn *WebSocketConn, group string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(h.conns[conn], group)
+	delete(h.groups[group], conn)
+
+	if len(h.groups[group]) == 0 {
+		delete(h.groups, group)
+	}
+}
+
+func sendAll(conns []*WebSocketConn, messageType int, data []byte) int {
+	sent := 0
+
+	for _, conn := range conns {
+		if conn.Send(messageType, data) == nil {
+			sent++
+		}
+	}
+
+	return sent
+}
+
+// Broadcast queues a message for all connections and returns the number of connections
+// it was queued for (see WebSocketConn.Send)
+func (h *WebSocketHub) Broadcast(messageType int, data []byte) int {
+	h.mutex.RLock()
+
+	conns := make([]*WebSocketConn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+
+	h.mutex.RUnlock()
+
+	return sendAll(conns, messageType, data)
+}
+
+// BroadcastGroup queues a message for all connections of a group and returns the number
+// of connections it was queued for (see WebSocketConn.Send)
+func (h *WebSocketHub) BroadcastGroup(group string, messageType int, data []byte) int {
+	h.mutex.RLock()
+
+	conns := make([]*WebSocketConn, 0, len(h.groups[group]))
+	for conn := range h.groups[group] {
+		conns = append(conns, conn)
+	}
+
+	h.mutex.RUnlock()
+
+	return sendAll(conns, messageType, data)
+}
+
+// Count returns the number of connections
+func (h *WebSocketHub) Count() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return len(h.conns)
+}
+
+// CountGroup returns the number of connections in a group
+func (h *WebSocketHub) CountGroup(group string) int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return len(h.groups[group])
+}
+
+// CloseAll closes all connections
+func (h *WebSocketHub) CloseAll(code int, reason string) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for conn := range h.conns {
+		conn.Close(code, reason)
+	}
+}
+
+// NewWebSocketHub creates a new initialized instance of WebSocketHub
+func NewWebSocketHub() *WebSocketHub {
+	return &WebSocketHub{
+		conns:  map[*WebSocketConn]map[string]bool{},
+		groups: map[string]map[*WebSocketConn]bool{},
+	}
+}
+
+// webSocketResponse logs a websocket connection after it was closed, the response
+// itself was already written by the upgrader
+type webSocketResponse struct {
+	request  *http.Request
+	conn     *WebSocketConn
+	duration time.Duration
+	err      error
+}
+
+var _ IResponse = (*webSocketResponse)(nil)
+
+func (r *webSocketResponse) GetRequest() *http.Request {
+	return r.request
+}
+
+func (r *webSocketResponse) Write(w http.ResponseWriter) IResponse {
+	return nil
+}
+
+func (r *webSocketResponse) Log(log *logger.Log) {
+	if r.err != nil {
+		log.Warnf("%s %s - Upgrade to websocket failed: %s", r.request.Method, r.request.RequestURI, r.err)
+
+		return
+	}
+
+	message := "%s %s - %d WebSocket %s closed with code %d after %s (%d messages received, %d sent)"
+	args := []interface{}{
+		r.request.Method,
+		r.request.RequestURI,
+		http.StatusSwitchingProtocols,
+		r.conn.ID,
+		r.conn.code,
+		r.duration.Round(time.Millisecond),
+		r.conn.received.Load(),
+		r.conn.sent.Load(),
+	}
+
+	err := r.conn.writeErr
+	if err == nil {
+		err = r.conn.readErr
+	}
+
+	if err != nil {
+		log.Warnf(message+": %s", append(args, err)...)
+
+		return
+	}
+
+	log.Infof(message, args...)
+}
+
+// WebSocket creates a handler function upgrading requests to websockets
+//
+// The connections are added to the hub (if not nil) and closed when the controller
+// is stopped. The handler function blocks until the connection is closed.
+//
+// e.g. router.Get("/ws", c.Wrap(c.WebSocket(hub, &gousuchi.WebSocketHandler{...}, nil)))
+func (c *AbstractController) WebSocket(hub *WebSocketHub, handler *WebSocketHandler, options *WebSocketOptions) HandlerFunction {
+	options = options.withDefaults()
+
+	if handler == nil {
+		handler = &WebSocketHandler{}
+	}
+
+	upgrader := &websocket.Upgrader{
+		CheckOrigin:  options.CheckOrigin,
+		Subprotocols: options.Subprotocols,
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) IResponse {
+		if options.Authenticate != nil {
+			var resp IResponse
+
+			r, resp = options.Authenticate(r)
+			if resp != nil {
+				return resp
+			}
+		}
+
+		c.mutexWebSockets.Lock()
+
+		select {
+		case <-c.Done():
+			c.mutexWebSockets.Unlock()
+
+			return ServiceUnavailable(r, "Server is stopping")
+		default:
+		}
+
+		c.webSockets.Add(1)
+		c.mutexWebSockets.Unlock()
+
+		defer c.webSockets.Done()
+
+		start := time.Now()
+
+		wsConn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// The upgrader already wrote an error response
+			return &webSocketResponse{
+				request: r,
+				err:     err,
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+		defer cancel()
+
+		conn := &WebSocketConn{
+			ID:      uuid.NewString(),
+			Request: r.WithContext(ctx),
+			conn:    wsConn,
+			hub:     hub,
+			options: options,
+			send:    make(chan *webSocketMessage, options.SendQueueSize),
+			ctx:     ctx,
+			closing: make(chan struct{}),
+			closed:  make(chan struct{}),
+			code:    websocket.CloseNormalClosure,
+		}
+
+		if hub != nil {
+			hub.add(conn)
+		}
+
+		go conn.writeLoop(c.Done())
+
+		if handler.OnConnect != nil {
+			handler.OnConnect(conn)
+		}
+
+		conn.readLoop(handler.OnMessage)
+
+		<-conn.closed
+
+		wsConn.Close()
+		cancel()
+
+		if hub != nil {
+			hub.remove(conn)
+		}
+
+		if handler.OnClose != nil {
+			handler.OnClose(conn)
+		}
+
+		return &webSocketResponse{
+			request:  r,
+			conn:     conn,
+			duration: time.Since(start),
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package gousuchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWebSocketServer(t *testing.T, c *AbstractController, hub *WebSocketHub, options *WebSocketOptions) *httptest.Server {
+	c.UseMetrics(nil)
+
+	c.router.Get("/ws", c.Wrap(c.WebSocket(hub, &WebSocketHandler{
+		OnConnect: func(conn *WebSocketConn) {
+			conn.Join(conn.Request.URL.Query().Get("group"))
+		},
+		OnMessage: func(conn *WebSocketConn, messageType int, data []byte) {
+			conn.Send(messageType, append([]byte("echo: "), data...))
+		},
+	}, options)))
+
+	server := httptest.NewServer(c.router)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func dialTestWebSocket(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?"+query, nil)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn
+}
+
+func readTestWebSocket(t *testing.T, conn *websocket.Conn) string {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestWebSocket(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+	hub := NewWebSocketHub()
+	server := newTestWebSocketServer(t, c, hub, nil)
+
+	connA := dialTestWebSocket(t, server, "group=a")
+	connB := dialTestWebSocket(t, server, "group=b")
+
+	require.NoError(t, connA.WriteMessage(websocket.TextMessage, []byte("hello")))
+	assert.Equal(t, "echo: hello", readTestWebSocket(t, connA))
+
+	require.Eventually(t, func() bool {
+		return hub.Count() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 1, hub.CountGroup("a"))
+	assert.Equal(t, 1, hub.BroadcastGroup("b", WebSocketTextMessage, []byte("to b")))
+	assert.Equal(t, "to b", readTestWebSocket(t, connB))
+
+	assert.Equal(t, 2, hub.Broadcast(WebSocketTextMessage, []byte("to all")))
+	assert.Equal(t, "to all", readTestWebSocket(t, connA))
+	assert.Equal(t, "to all", readTestWebSocket(t, connB))
+
+	connB.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+
+	require.Eventually(t, func() bool {
+		return hub.Count() == 1 && hub.CountGroup("b") == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWebSocketReadLimit(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+	server := newTestWebSocketServer(t, c, nil, &WebSocketOptions{ReadLimit: 8})
+
+	conn := dialTestWebSocket(t, server, "")
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("too long message")))
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), err)
+}
+
+func TestWebSocketAuthenticate(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+	server := newTestWebSocketServer(t, c, nil, &WebSocketOptions{
+		Authenticate: func(r *http.Request) (*http.Request, IResponse) {
+			return r, Unauthorized(r, "Missing token")
+		},
+	})
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestWebSocketStop(t *testing.T) {
+	c := NewAbstractController(logger.GetLogger("test"))
+	hub := NewWebSocketHub()
+	server := newTestWebSocketServer(t, c, hub, nil)
+
+	conn := dialTestWebSocket(t, server, "")
+
+	require.Eventually(t, func() bool {
+		return hub.Count() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	closeCode := make(chan int, 1)
+
+	go func() {
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				closeError := &websocket.CloseError{}
+				if assert.ErrorAs(t, err, &closeError) {
+					closeCode <- closeError.Code
+				}
+
+				return
+			}
+		}
+	}()
+
+	require.NoError(t, c.Stop())
+
+	assert.Equal(t, 0, hub.Count())
+	assert.Equal(t, websocket.CloseGoingAway, <-closeCode)
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
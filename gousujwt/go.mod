@@ -6,7 +6,27 @@ toolchain go1.22.4
 
 replace github.com/indece-official/go-gousu/v2 => ../
 
-require github.com/indece-official/go-gousu/v2 v2.3.0
+replace github.com/indece-official/go-gousu/gousuchi/v2 => ../gousuchi
+
+require (
+	// Middleware and WebSocketAuthenticator are shipped with gousuchi v2.4.0
+	github.com/indece-official/go-gousu/gousuchi/v2 v2.4.0
+	github.com/indece-official/go-gousu/v2 v2.3.0
+	github.com/stretchr/testify v1.10.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-chi/chi/v5 v5.0.12 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/gorilla/websocket v1.5.3 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.4.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
 
 require (
 	github.com/MicahParks/keyfunc v1.9.0
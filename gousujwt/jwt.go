@@ -2,6 +2,9 @@ package gousujwt
 
 import (
 	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -30,6 +33,34 @@ var (
 	jwtVerifyNoSuccessSiemEvent = flag.Bool("jwt_verify_no_success_siem_event", true, "Don't log success siem event from JWT-Verifier")
 )
 
+// Errors returned by the verifier wrap one of these errors, so callers can distinguish
+// between missing or invalid tokens (401) and valid tokens lacking groups (403)
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// verifyError is an error with its own message wrapping ErrUnauthorized or ErrForbidden
+type verifyError struct {
+	err     error
+	message string
+}
+
+func (e *verifyError) Error() string {
+	return e.message
+}
+
+func (e *verifyError) Unwrap() error {
+	return e.err
+}
+
+func newVerifyError(err error, msg string, args ...interface{}) error {
+	return &verifyError{
+		err:     err,
+		message: fmt.Sprintf(msg, args...),
+	}
+}
+
 // IJWTVerifier defines the interface of JWTVerifier
 type IVerifier interface {
 	Verify(w http.ResponseWriter, r *http.Request, groups []string) (*CustomClaims, error)
@@ -129,6 +160,33 @@ func (j *Verifier) load() error {
 	return nil
 }
 
+// tokenFingerprint returns an identifier for a jwt which is safe to be logged
+//
+// It consists of a hash of the token's signature and the token's id (jti) if
+// available. The id is only included for tokens with a verified signature, as
+// it is chosen by the client otherwise.
+func tokenFingerprint(authToken string, verified bool) string {
+	if authToken == "" {
+		return "<empty>"
+	}
+
+	parts := strings.Split(authToken, ".")
+	hash := sha256.Sum256([]byte(parts[len(parts)-1]))
+	fingerprint := fmt.Sprintf("sha256:%s", hex.EncodeToString(hash[:8]))
+
+	if !verified {
+		return fingerprint
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(authToken, claims)
+	if err == nil && claims.ID != "" {
+		fingerprint = fmt.Sprintf("jti:%s/%s", claims.ID, fingerprint)
+	}
+
+	return fingerprint
+}
+
 func (j *Verifier) logSiemEvent(
 	r *http.Request,
 	eventType siem.EventType,
@@ -166,10 +224,10 @@ func (j *Verifier) VerifyTokenWithCustomClaims(r *http.Request, authToken string
 				siem.EventTypeAuthenticationFailedAttact,
 				nil,
 				"Invalid jwt token: %s",
-				authToken,
+				tokenFingerprint(authToken, false),
 			)
 
-			return nil, fmt.Errorf("authorization failed: invalid token")
+			return nil, newVerifyError(ErrUnauthorized, "authorization failed: invalid token")
 		}
 
 		err = token.Claims.Valid()
@@ -179,11 +237,11 @@ func (j *Verifier) VerifyTokenWithCustomClaims(r *http.Request, authToken string
 				siem.EventTypeAuthenticationFailedAttact,
 				nil,
 				"Invalid jwt claims in token %s: %s",
-				authToken,
+				tokenFingerprint(authToken, true),
 				err,
 			)
 
-			return nil, fmt.Errorf("authorization failed: invalid claims in token: %s", err)
+			return nil, newVerifyError(ErrUnauthorized, "authorization failed: invalid claims in token: %s", err)
 		}
 
 		if !*jwtSkipVerifyAlgorithm && token.Method.Alg() != *jwtVerifyAlgorithm {
@@ -192,12 +250,12 @@ func (j *Verifier) VerifyTokenWithCustomClaims(r *http.Request, authToken string
 				siem.EventTypeAuthenticationFailedAttact,
 				nil,
 				"Invalid jwt token: %s - missmatching algorithm: got %s, expected %s",
-				authToken,
+				tokenFingerprint(authToken, false),
 				token.Method.Alg(),
 				*jwtVerifyAlgorithm,
 			)
 
-			return nil, fmt.Errorf("missmatching algorithm: got %s, expected %s", token.Method.Alg(), *jwtVerifyAlgorithm)
+			return nil, newVerifyError(ErrUnauthorized, "missmatching algorithm: got %s, expected %s", token.Method.Alg(), *jwtVerifyAlgorithm)
 		}
 
 		customClaims, ok := token.Claims.(ICustomClaims)
@@ -207,10 +265,10 @@ func (j *Verifier) VerifyTokenWithCustomClaims(r *http.Request, authToken string
 				siem.EventTypeAuthenticationFailedAttact,
 				nil,
 				"Invalid jwt token: %s - casting jwt custom claims failed",
-				*jwtVerifyAlgorithm,
+				tokenFingerprint(authToken, true),
 			)
 
-			return nil, fmt.Errorf("casting jwt custom claims failed")
+			return nil, newVerifyError(ErrUnauthorized, "casting jwt custom claims failed")
 		}
 
 		if !*jwtSkipVerifyAudience && !gousu.ContainsString(customClaims.GetAudiences(), *jwtVerifyAudience) {
@@ -219,12 +277,12 @@ func (j *Verifier) VerifyTokenWithCustomClaims(r *http.Request, authToken string
 				siem.EventTypeAuthenticationFailedAttact,
 				nil,
 				"Invalid jwt token: %s - missmatching audience: got %v, expected %s",
-				authToken,
+				tokenFingerprint(authToken, true),
 				customClaims.GetAudiences(),
 				*jwtVerifyAudience,
 			)
 
-			return nil, fmt.Errorf("missmatching audience: got %v, expected %s", customClaims.GetAudiences(), *jwtVerifyAudience)
+			return nil, newVerifyError(ErrUnauthorized, "missmatching audience: got %v, expected %s", customClaims.GetAudiences(), *jwtVerifyAudience)
 		}
 
 		for i := range groups {
@@ -234,11 +292,11 @@ func (j *Verifier) VerifyTokenWithCustomClaims(r *http.Request, authToken string
 					siem.EventTypeAuthenticationFailedAttact,
 					nil,
 					"Authorization failed: missing group in token %s: %s",
-					authToken,
+					tokenFingerprint(authToken, true),
 					groups[i],
 				)
 
-				return nil, fmt.Errorf("authorization failed: missing group %s", groups[i])
+				return nil, newVerifyError(ErrForbidden, "authorization failed: missing group %s", groups[i])
 			}
 		}
 
@@ -257,15 +315,16 @@ func (j *Verifier) VerifyTokenWithCustomClaims(r *http.Request, authToken string
 	case *jwt.ValidationError: // something was wrong during the validation
 		switch typedErr.Errors {
 		case jwt.ValidationErrorExpired:
+			// Claims are only validated after the signature was verified
 			j.logSiemEvent(
 				r,
 				siem.EventTypeAuthenticationFailed,
 				nil,
 				"Authorization failed: jwt expired: %s",
-				authToken,
+				tokenFingerprint(authToken, true),
 			)
 
-			return nil, fmt.Errorf("authorization failed: JWT expired")
+			return nil, newVerifyError(ErrUnauthorized, "authorization failed: JWT expired")
 
 		default:
 			j.logSiemEvent(
@@ -273,11 +332,11 @@ func (j *Verifier) VerifyTokenWithCustomClaims(r *http.Request, authToken string
 				siem.EventTypeAuthenticationFailedAttact,
 				nil,
 				"Authorization failed: invalid jwt %s: %s",
-				authToken,
+				tokenFingerprint(authToken, false),
 				err,
 			)
 
-			return nil, fmt.Errorf("authorization failed: invalid JWT: %s", err)
+			return nil, newVerifyError(ErrUnauthorized, "authorization failed: invalid JWT: %s", err)
 		}
 
 	default: // something else went wrong
@@ -286,11 +345,11 @@ func (j *Verifier) VerifyTokenWithCustomClaims(r *http.Request, authToken string
 			siem.EventTypeAuthenticationFailedAttact,
 			nil,
 			"Authorization failed for token %s: %s",
-			authToken,
+			tokenFingerprint(authToken, false),
 			err,
 		)
 
-		return nil, fmt.Errorf("authorization failed: %s", err)
+		return nil, newVerifyError(ErrUnauthorized, "authorization failed: %s", err)
 	}
 }
 
@@ -307,11 +366,10 @@ func (j *Verifier) VerifyWithCustomClaims(w http.ResponseWriter, r *http.Request
 			r,
 			siem.EventTypeAuthenticationFailed,
 			nil,
-			"Invalid or empty authorization header: %s",
-			r.Header.Get("Authorization"),
+			"Invalid or empty authorization header",
 		)
 
-		return nil, fmt.Errorf("invalid authorization header")
+		return nil, newVerifyError(ErrUnauthorized, "invalid authorization header")
 	}
 
 	return j.VerifyTokenWithCustomClaims(r, authorizationHeader[1], groups, claims)
@@ -1 +1,33 @@
 package gousujwt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenFingerprint(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.RegisteredClaims{ID: "abc123"}).SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	fingerprint := tokenFingerprint(token, true)
+
+	assert.True(t, strings.HasPrefix(fingerprint, "jti:abc123/sha256:"))
+	assert.NotContains(t, fingerprint, strings.Split(token, ".")[2])
+	assert.Equal(t, fingerprint, tokenFingerprint(token, true))
+
+	// The jti of unverified tokens is chosen by the client
+	fingerprint = tokenFingerprint(token, false)
+
+	assert.True(t, strings.HasPrefix(fingerprint, "sha256:"))
+	assert.NotContains(t, fingerprint, "abc123")
+
+	fingerprint = tokenFingerprint("invalid", false)
+
+	assert.True(t, strings.HasPrefix(fingerprint, "sha256:"))
+	assert.Len(t, fingerprint, len("sha256:")+16)
+
+	assert.Equal(t, "<empty>", tokenFingerprint("", false))
+}
@@ -0,0 +1,101 @@
+package gousujwt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/indece-official/go-gousu/gousuchi/v2"
+)
+
+// LogFieldUserID is the log field containing the id of the authenticated user
+const LogFieldUserID = "user_id"
+
+type claimsContextKey struct{}
+
+// ClaimsFromContext returns the claims stored by the middleware, nil if
+// the request was not authenticated
+func ClaimsFromContext(ctx context.Context) ICustomClaims {
+	claims, _ := ctx.Value(claimsContextKey{}).(ICustomClaims)
+
+	return claims
+}
+
+// GetClaims returns the claims of an authenticated request, nil if the
+// request was not authenticated
+func GetClaims(r *http.Request) ICustomClaims {
+	return ClaimsFromContext(r.Context())
+}
+
+// authenticate stores the verified claims in the request's context or returns an
+// Unauthorized- or Forbidden-Response if the verification failed
+func authenticate(
+	controller *gousuchi.AbstractController,
+	r *http.Request,
+	claims ICustomClaims,
+	err error,
+) (*http.Request, *gousuchi.ResponseError) {
+	if err != nil {
+		if errors.Is(err, ErrForbidden) {
+			return r, gousuchi.Forbidden(r, "JWT verification failed: %s", err)
+		}
+
+		return r, gousuchi.Unauthorized(r, "JWT verification failed: %s", err).
+			WithHeader("WWW-Authenticate", "Bearer")
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims))
+	r = controller.WithExtra(r, LogFieldUserID, claims.GetUserID())
+
+	return r, nil
+}
+
+// NewMiddlewareWithCustomClaims creates a chi middleware verifying the JWT from the
+// authorization header and checking the required groups
+//
+// Tokens are decoded into the claims returned by newClaims. The claims are stored in
+// the request's context (see GetClaims) and the user id is added as log field. Missing
+// or invalid tokens are rejected with Unauthorized, tokens lacking a group with Forbidden.
+func NewMiddlewareWithCustomClaims(
+	verifier IVerifier,
+	controller *gousuchi.AbstractController,
+	groups []string,
+	newClaims func() ICustomClaims,
+) gousuchi.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := verifier.VerifyWithCustomClaims(w, r, groups, newClaims())
+
+			r, resp := authenticate(controller, r, claims, err)
+			if resp != nil {
+				resp.Write(w)
+				resp.Log(controller.GetLog(r))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NewMiddleware creates a chi middleware verifying the JWT with CustomClaims
+// (see NewMiddlewareWithCustomClaims)
+func NewMiddleware(verifier IVerifier, controller *gousuchi.AbstractController, groups []string) gousuchi.Middleware {
+	return NewMiddlewareWithCustomClaims(verifier, controller, groups, func() ICustomClaims {
+		return &CustomClaims{}
+	})
+}
+
+// Protect wraps a handler function, so it is only called for requests with a
+// valid JWT containing the groups (see NewMiddleware)
+//
+// e.g. router.Get("/users", gousujwt.Protect(verifier, c.AbstractController, []string{"admin"}, c.getUsers))
+func Protect(
+	verifier IVerifier,
+	controller *gousuchi.AbstractController,
+	groups []string,
+	clb gousuchi.HandlerFunction,
+) http.HandlerFunc {
+	return NewMiddleware(verifier, controller, groups)(http.HandlerFunc(controller.Wrap(clb))).ServeHTTP
+}
@@ -0,0 +1,66 @@
+package gousujwt
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/indece-official/go-gousu/gousuchi/v2"
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	verifier := NewMockVerifier()
+	controller := gousuchi.NewAbstractController(logger.GetLogger("test"))
+	controller.UseMetrics(nil)
+
+	verifier.VerifyWithCustomClaimsFunc = func(w http.ResponseWriter, r *http.Request, groups []string, claims ICustomClaims) (ICustomClaims, error) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer admin":
+			return &CustomClaims{UserID: 1, Groups: []string{"admin"}}, nil
+		case "Bearer user":
+			return nil, newVerifyError(ErrForbidden, "authorization failed: missing group %s", groups[0])
+		default:
+			return nil, newVerifyError(ErrUnauthorized, "invalid authorization header")
+		}
+	}
+
+	handler := Protect(verifier, controller, []string{"admin"}, func(w http.ResponseWriter, r *http.Request) gousuchi.IResponse {
+		return gousuchi.Text(r, fmt.Sprintf("user %d", GetClaims(r).GetUserID()))
+	})
+
+	for _, testCase := range []struct {
+		authorization string
+		statusCode    int
+	}{
+		{"Bearer admin", http.StatusOK},
+		{"Bearer user", http.StatusForbidden},
+		{"", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest("GET", "/users", nil)
+		req.Header.Set("Authorization", testCase.authorization)
+
+		writer := httptest.NewRecorder()
+		handler(writer, req)
+
+		assert.Equal(t, testCase.statusCode, writer.Code, testCase.authorization)
+
+		if testCase.statusCode == http.StatusOK {
+			assert.Equal(t, "user 1", writer.Body.String())
+		}
+
+		if testCase.statusCode == http.StatusUnauthorized {
+			assert.Equal(t, "Bearer", writer.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	err := newVerifyError(ErrForbidden, "authorization failed: missing group %s", "admin")
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+	assert.EqualError(t, err, "authorization failed: missing group admin")
+}
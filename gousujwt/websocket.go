@@ -0,0 +1,51 @@
+package gousujwt
+
+import (
+	"net/http"
+
+	"github.com/indece-official/go-gousu/gousuchi/v2"
+)
+
+// QueryParamAccessToken is the query parameter containing the JWT of websocket requests,
+// as browsers can't set the authorization header for websockets (RFC 6750)
+const QueryParamAccessToken = "access_token"
+
+// NewWebSocketAuthenticatorWithCustomClaims creates an authenticator for websockets verifying
+// the JWT and checking the required groups (see gousuchi.WebSocketOptions)
+//
+// The JWT is loaded from the authorization header or the query parameter access_token.
+// The claims are stored in the context of the connection's request (see GetClaims). Note
+// that tokens in the query are part of the request uri, which is logged by gousuchi.
+func NewWebSocketAuthenticatorWithCustomClaims(
+	verifier IVerifier,
+	controller *gousuchi.AbstractController,
+	groups []string,
+	newClaims func() ICustomClaims,
+) gousuchi.WebSocketAuthenticator {
+	return func(r *http.Request) (*http.Request, gousuchi.IResponse) {
+		var claims ICustomClaims
+		var err error
+
+		accessToken := r.URL.Query().Get(QueryParamAccessToken)
+		if r.Header.Get("Authorization") == "" && accessToken != "" {
+			claims, err = verifier.VerifyTokenWithCustomClaims(r, accessToken, groups, newClaims())
+		} else {
+			claims, err = verifier.VerifyWithCustomClaims(nil, r, groups, newClaims())
+		}
+
+		r, resp := authenticate(controller, r, claims, err)
+		if resp != nil {
+			return r, resp
+		}
+
+		return r, nil
+	}
+}
+
+// NewWebSocketAuthenticator creates an authenticator for websockets verifying the JWT
+// with CustomClaims (see NewWebSocketAuthenticatorWithCustomClaims)
+func NewWebSocketAuthenticator(verifier IVerifier, controller *gousuchi.AbstractController, groups []string) gousuchi.WebSocketAuthenticator {
+	return NewWebSocketAuthenticatorWithCustomClaims(verifier, controller, groups, func() ICustomClaims {
+		return &CustomClaims{}
+	})
+}
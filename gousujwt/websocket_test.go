@@ -0,0 +1,44 @@
+package gousujwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/indece-official/go-gousu/gousuchi/v2"
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocketAuthenticator(t *testing.T) {
+	verifier := NewMockVerifier()
+	controller := gousuchi.NewAbstractController(logger.GetLogger("test"))
+
+	verifier.VerifyTokenWithCustomClaimsFunc = func(r *http.Request, authToken string, groups []string, claims ICustomClaims) (ICustomClaims, error) {
+		if authToken != "admin" {
+			return nil, newVerifyError(ErrUnauthorized, "authorization failed: invalid token")
+		}
+
+		return &CustomClaims{UserID: 2}, nil
+	}
+
+	authenticate := NewWebSocketAuthenticator(verifier, controller, []string{"admin"})
+
+	r, resp := authenticate(httptest.NewRequest("GET", "/ws?access_token=admin", nil))
+	require.Nil(t, resp)
+	assert.Equal(t, int64(2), GetClaims(r).GetUserID())
+	assert.Equal(t, 1, verifier.VerifyTokenWithCustomClaimsFuncCalled)
+
+	_, resp = authenticate(httptest.NewRequest("GET", "/ws?access_token=user", nil))
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.(*gousuchi.ResponseError).StatusCode)
+
+	req := httptest.NewRequest("GET", "/ws?access_token=admin", nil)
+	req.Header.Set("Authorization", "Bearer admin")
+
+	r, resp = authenticate(req)
+	require.Nil(t, resp)
+	assert.NotNil(t, GetClaims(r))
+	assert.Equal(t, 1, verifier.VerifyWithCustomClaimsFuncCalled)
+}
@@ -0,0 +1,190 @@
+package gousupostgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/indece-official/go-gousu/v2/gousu/siem"
+)
+
+var regexpTableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// SiemAuditStore is a siem.IAuditStore storing the hash-chained audit trail
+// in a postgres table
+//
+// Appending locks the table, so multiple replicas can write to the same trail
+type SiemAuditStore struct {
+	service IService
+	table   string
+	key     []byte
+}
+
+var _ siem.IAuditStore = (*SiemAuditStore)(nil)
+
+// EnsureTable creates the audit table if it does not exist
+func (s *SiemAuditStore) EnsureTable() error {
+	db, err := s.service.GetDBSafe()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (
+			seq BIGINT PRIMARY KEY,
+			time TIMESTAMPTZ NOT NULL,
+			type TEXT NOT NULL,
+			hash TEXT NOT NULL,
+			prev_hash TEXT NOT NULL,
+			data TEXT NOT NULL
+		)`,
+		s.table,
+	))
+	if err != nil {
+		return fmt.Errorf("can't create audit table %s: %s", s.table, err)
+	}
+
+	return nil
+}
+
+// Append links the record to the last record in the table and inserts it
+func (s *SiemAuditStore) Append(record *siem.AuditRecord) error {
+	db, err := s.service.GetDBSafe()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("can't start transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(fmt.Sprintf("LOCK TABLE %s IN EXCLUSIVE MODE", s.table))
+	if err != nil {
+		return fmt.Errorf("can't lock audit table %s: %s", s.table, err)
+	}
+
+	var prev *siem.AuditRecord
+
+	var prevData string
+
+	err = tx.QueryRow(fmt.Sprintf("SELECT data FROM %s ORDER BY seq DESC LIMIT 1", s.table)).Scan(&prevData)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("can't load last audit record: %s", err)
+	}
+
+	if err == nil {
+		prev = &siem.AuditRecord{}
+
+		err = json.Unmarshal([]byte(prevData), prev)
+		if err != nil {
+			return fmt.Errorf("can't decode last audit record: %s", err)
+		}
+	}
+
+	err = record.Link(prev, s.key)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("can't encode audit record: %s", err)
+	}
+
+	_, err = tx.Exec(
+		fmt.Sprintf("INSERT INTO %s (seq, time, type, hash, prev_hash, data) VALUES ($1, $2, $3, $4, $5, $6)", s.table),
+		record.Sequence,
+		record.Time,
+		record.Type,
+		record.Hash,
+		record.PrevHash,
+		string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("can't insert audit record: %s", err)
+	}
+
+	return tx.Commit()
+}
+
+// Iterate calls fn for all records in the table ordered by their sequence
+//
+// The columns seq, time, type, hash and prev_hash must match the stored record,
+// else the record is reported as modified
+func (s *SiemAuditStore) Iterate(fn func(record *siem.AuditRecord) error) error {
+	db, err := s.service.GetDBSafe()
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Query(fmt.Sprintf("SELECT seq, time, type, hash, prev_hash, data FROM %s ORDER BY seq ASC", s.table))
+	if err != nil {
+		return fmt.Errorf("can't load audit records: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seq int64
+		var recordTime time.Time
+		var recordType string
+		var hash string
+		var prevHash string
+		var data string
+
+		err = rows.Scan(&seq, &recordTime, &recordType, &hash, &prevHash, &data)
+		if err != nil {
+			return fmt.Errorf("can't load audit record: %s", err)
+		}
+
+		record := &siem.AuditRecord{}
+
+		err = json.Unmarshal([]byte(data), record)
+		if err != nil {
+			return fmt.Errorf("can't decode audit record %d: %s", seq, err)
+		}
+
+		if record.Sequence != seq ||
+			!record.Time.Equal(recordTime) ||
+			string(record.Type) != recordType ||
+			record.Hash != hash ||
+			record.PrevHash != prevHash {
+			return &siem.AuditVerificationError{
+				Sequence: seq,
+				Reason:   "record columns do not match stored record",
+			}
+		}
+
+		err = fn(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Close currently does nothing, the database connection is managed by the postgres service
+func (s *SiemAuditStore) Close() error {
+	return nil
+}
+
+// NewSiemAuditStore creates a new initialized instance of SiemAuditStore
+//
+// The table name may contain a schema (e.g. "audit.siem_events"). Records are
+// chained with the given key (see siem.AuditRecord), an empty key uses a plain
+// sha256 hash chain
+func NewSiemAuditStore(service IService, table string, key []byte) (*SiemAuditStore, error) {
+	if !regexpTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid audit table name '%s'", table)
+	}
+
+	return &SiemAuditStore{
+		service: service,
+		table:   table,
+		key:     key,
+	}, nil
+}
@@ -0,0 +1,192 @@
+package gousupostgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/indece-official/go-gousu/v2/gousu/siem"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v4"
+)
+
+// auditTestDB is a minimal database/sql driver storing the rows of the audit
+// table in memory, it only supports the queries of SiemAuditStore
+type auditTestDB struct {
+	rows [][]driver.Value
+}
+
+type auditTestConn struct {
+	db *auditTestDB
+}
+
+type auditTestStmt struct {
+	db    *auditTestDB
+	query string
+}
+
+type auditTestRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (d *auditTestDB) Connect(ctx context.Context) (driver.Conn, error) {
+	return &auditTestConn{db: d}, nil
+}
+
+func (d *auditTestDB) Driver() driver.Driver {
+	return nil
+}
+
+func (c *auditTestConn) Prepare(query string) (driver.Stmt, error) {
+	return &auditTestStmt{db: c.db, query: query}, nil
+}
+
+func (c *auditTestConn) Close() error {
+	return nil
+}
+
+func (c *auditTestConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c *auditTestConn) Commit() error {
+	return nil
+}
+
+func (c *auditTestConn) Rollback() error {
+	return nil
+}
+
+func (s *auditTestStmt) Close() error {
+	return nil
+}
+
+func (s *auditTestStmt) NumInput() int {
+	return -1
+}
+
+func (s *auditTestStmt) Exec(args []driver.Value) (driver.Result, error) {
+	switch {
+	case strings.HasPrefix(s.query, "LOCK TABLE"):
+	case strings.HasPrefix(s.query, "INSERT INTO"):
+		s.db.rows = append(s.db.rows, args)
+	default:
+		return nil, fmt.Errorf("unsupported query '%s'", s.query)
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *auditTestStmt) Query(args []driver.Value) (driver.Rows, error) {
+	switch {
+	case strings.HasPrefix(s.query, "SELECT data ") && strings.Contains(s.query, "ORDER BY seq DESC LIMIT 1"):
+		rows := &auditTestRows{columns: []string{"data"}}
+		if len(s.db.rows) > 0 {
+			rows.values = append(rows.values, []driver.Value{s.db.rows[len(s.db.rows)-1][5]})
+		}
+
+		return rows, nil
+	case strings.HasPrefix(s.query, "SELECT seq, time, type, hash, prev_hash, data "):
+		return &auditTestRows{
+			columns: []string{"seq", "time", "type", "hash", "prev_hash", "data"},
+			values:  s.db.rows,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported query '%s'", s.query)
+	}
+}
+
+func (r *auditTestRows) Columns() []string {
+	return r.columns
+}
+
+func (r *auditTestRows) Close() error {
+	return nil
+}
+
+func (r *auditTestRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
+
+func TestNewSiemAuditStore(t *testing.T) {
+	service := NewMockService()
+
+	store, err := NewSiemAuditStore(service, "audit.siem_events", nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, store)
+
+	_, err = NewSiemAuditStore(service, "siem_events; DROP TABLE users", nil)
+	assert.Error(t, err)
+}
+
+func TestSiemAuditStoreAppendIterate(t *testing.T) {
+	testDB := &auditTestDB{}
+	db := sql.OpenDB(testDB)
+	defer db.Close()
+
+	service := NewMockService()
+	service.GetDBSafeFunc = func() (*sql.DB, error) {
+		return db, nil
+	}
+
+	key := []byte("test-audit-key")
+
+	store, err := NewSiemAuditStore(service, "siem_events", key)
+	assert.NoError(t, err)
+
+	sink := siem.NewAuditSink(store)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, sink.Write(&siem.Record{
+			Time: time.Date(2024, 1, 2, 3, 4, 5+i, 0, time.UTC),
+			Event: &siem.Event{
+				Type:           siem.EventTypeLoginFailed,
+				UserIdentifier: null.StringFrom("userid:5"),
+			},
+			Level:   siem.EventLevelWarn,
+			Message: "Login failed",
+		}))
+	}
+
+	records := []*siem.AuditRecord{}
+	err = store.Iterate(func(record *siem.AuditRecord) error {
+		records = append(records, record)
+
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, int64(3), records[2].Sequence)
+	assert.Equal(t, records[1].Hash, records[2].PrevHash)
+	assert.Equal(t, siem.EventTypeLoginFailed, records[2].Type)
+
+	count, err := siem.VerifyAudit(store, key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// Modified time and type columns are detected
+	testDB.rows[1][1] = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	count, err = siem.VerifyAudit(store, key)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, &siem.AuditVerificationError{Sequence: 2, Reason: "record columns do not match stored record"}, err)
+
+	testDB.rows[1][1] = time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC)
+	testDB.rows[2][2] = string(siem.EventTypeLoginSuccess)
+
+	count, err = siem.VerifyAudit(store, key)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, &siem.AuditVerificationError{Sequence: 3, Reason: "record columns do not match stored record"}, err)
+}
@@ -0,0 +1,359 @@
+package gousuredis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/indece-official/go-gousu/v2/gousu/broadcaster"
+	"github.com/indece-official/go-gousu/v2/gousu/logger"
+)
+
+// ICodec encodes and decodes the values of a Broadcaster
+type ICodec[T any] interface {
+	Encode(value T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// JSONCodec encodes values as json
+type JSONCodec[T any] struct{}
+
+var _ ICodec[bool] = JSONCodec[bool]{}
+
+// Encode encodes the value as json
+func (c JSONCodec[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode decodes the value from json
+func (c JSONCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+
+	err := json.Unmarshal(data, &value)
+
+	return value, err
+}
+
+// ErrorCodec encodes errors by their message, nil is encoded as an empty message
+type ErrorCodec struct{}
+
+var _ ICodec[error] = ErrorCodec{}
+
+// Encode encodes the message of the error
+func (c ErrorCodec) Encode(value error) ([]byte, error) {
+	if value == nil {
+		return []byte{}, nil
+	}
+
+	return []byte(value.Error()), nil
+}
+
+// Decode creates a new error from the message
+func (c ErrorCodec) Decode(data []byte) (error, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	return errors.New(string(data)), nil
+}
+
+// BroadcasterOptions configures a Broadcaster
+type BroadcasterOptions struct {
+	// Key stores the last value for bootstrapping (defaults to the channel name)
+	Key string
+	// ReconnectMinDelay is the initial delay before resubscribing after an error (defaults to 500ms)
+	ReconnectMinDelay time.Duration
+	// ReconnectMaxDelay is the maximum delay before resubscribing after an error (defaults to 30s)
+	ReconnectMaxDelay time.Duration
+}
+
+type broadcasterEnvelope struct {
+	Origin string `json:"origin"`
+	Data   []byte `json:"data"`
+}
+
+// Broadcaster keeps a broadcaster.Generic in sync across multiple replicas
+//
+// All values passed to Next of the local broadcaster are published on a redis
+// channel and stored in a redis key. Values received on the channel are passed to
+// Next of the local broadcaster. The last value is loaded from the key on Start and
+// after each reconnect.
+//
+// Each message is tagged with the id of the publishing instance, so instances skip
+// their own messages. Values received from redis are passed to the local broadcaster
+// via NextExcept, so they are not published again.
+//
+// Values are forwarded to redis with DeliveryPolicyDropOldest, so a stalled redis
+// doesn't block Next of the local broadcaster, but intermediate values may be skipped.
+type Broadcaster[T comparable] struct {
+	service      IService
+	channel      string
+	target       *broadcaster.Generic[T]
+	codec        ICodec[T]
+	options      BroadcasterOptions
+	origin       string
+	log          *logger.Log
+	subscription *broadcaster.Subscription
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+}
+
+// applyRemote passes a value received from redis to the local broadcaster
+// without forwarding it to redis again
+func (b *Broadcaster[T]) applyRemote(value T) {
+	b.target.NextExcept(value, b.subscription)
+}
+
+func (b *Broadcaster[T]) bootstrap() error {
+	data, err := b.service.Get(b.options.Key)
+	if err != nil && err != ErrNil {
+		return fmt.Errorf("can't load value from redis: %s", err)
+	}
+
+	if err == ErrNil || len(data) == 0 {
+		return nil
+	}
+
+	value, err := b.codec.Decode(data)
+	if err != nil {
+		return fmt.Errorf("can't decode value: %s", err)
+	}
+
+	if value != b.target.Value() {
+		b.applyRemote(value)
+	}
+
+	return nil
+}
+
+// connect subscribes to the channel and loads the last value afterwards, so no
+// updates get lost in between
+func (b *Broadcaster[T]) connect() (chan Message, ISubscription, error) {
+	messages, subscription, err := b.service.Subscribe([]string{b.channel})
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't subscribe to redis channel %s: %s", b.channel, err)
+	}
+
+	err = b.bootstrap()
+	if err != nil {
+		subscription.Close()
+
+		return nil, nil, err
+	}
+
+	return messages, subscription, nil
+}
+
+func (b *Broadcaster[T]) consume(ctx context.Context, messages chan Message) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message := <-messages:
+			if message.IsError() {
+				return message.Error
+			}
+
+			envelope := &broadcasterEnvelope{}
+
+			err := json.Unmarshal(message.Data, envelope)
+			if err != nil {
+				b.log.Warnf("Can't decode message on redis channel %s: %s", b.channel, err)
+
+				continue
+			}
+
+			if envelope.Origin == b.origin {
+				continue
+			}
+
+			value, err := b.codec.Decode(envelope.Data)
+			if err != nil {
+				b.log.Warnf("Can't decode value on redis channel %s: %s", b.channel, err)
+
+				continue
+			}
+
+			b.applyRemote(value)
+		}
+	}
+}
+
+func (b *Broadcaster[T]) receive(ctx context.Context, messages chan Message, subscription ISubscription) {
+	defer b.wg.Done()
+
+	delay := b.options.ReconnectMinDelay
+
+	for {
+		err := b.consume(ctx, messages)
+		subscription.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		b.log.Warnf("Subscription to redis channel %s failed: %s", b.channel, err)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			messages, subscription, err = b.connect()
+			if err == nil {
+				break
+			}
+
+			delay *= 2
+			if delay > b.options.ReconnectMaxDelay {
+				delay = b.options.ReconnectMaxDelay
+			}
+
+			b.log.Warnf("Can't reconnect to redis channel %s (retrying in %s): %s", b.channel, delay, err)
+		}
+
+		delay = b.options.ReconnectMinDelay
+	}
+}
+
+func (b *Broadcaster[T]) publish(value T) error {
+	data, err := b.codec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("can't encode value: %s", err)
+	}
+
+	err = b.service.Set(b.options.Key, data)
+	if err != nil {
+		return fmt.Errorf("can't store value in redis: %s", err)
+	}
+
+	envelope, err := json.Marshal(&broadcasterEnvelope{
+		Origin: b.origin,
+		Data:   data,
+	})
+	if err != nil {
+		return fmt.Errorf("can't encode message: %s", err)
+	}
+
+	err = b.service.Publish(b.channel, envelope)
+	if err != nil {
+		return fmt.Errorf("can't publish value on redis channel %s: %s", b.channel, err)
+	}
+
+	return nil
+}
+
+func (b *Broadcaster[T]) forward(ctx context.Context, values chan T) {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case value, ok := <-values:
+			if !ok {
+				// The target was closed
+				return
+			}
+
+			err := b.publish(value)
+			if err != nil {
+				b.log.Errorf("Can't forward value: %s", err)
+			}
+		}
+	}
+}
+
+// Start loads the last value from redis and starts syncing the broadcaster
+func (b *Broadcaster[T]) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Subscribe before bootstrapping, so the bootstrapped value is excluded from forwarding
+	values, localSubscription := b.target.Subscribe(
+		broadcaster.WithPolicy(broadcaster.DeliveryPolicyDropOldest),
+		broadcaster.WithBufferSize(16),
+	)
+	b.subscription = localSubscription
+
+	messages, subscription, err := b.connect()
+	if err != nil {
+		localSubscription.Unsubscribe()
+		cancel()
+
+		return err
+	}
+
+	b.cancel = cancel
+
+	b.wg.Add(2)
+	go b.receive(ctx, messages, subscription)
+	go b.forward(ctx, values)
+
+	return nil
+}
+
+// Stop stops syncing the broadcaster
+func (b *Broadcaster[T]) Stop() error {
+	if b.cancel == nil {
+		return nil
+	}
+
+	b.cancel()
+	b.wg.Wait()
+	b.subscription.Unsubscribe()
+
+	b.cancel = nil
+
+	return nil
+}
+
+// NewBroadcaster creates a new initialized instance of Broadcaster syncing the
+// target broadcaster via the given redis channel
+//
+// options can be nil to use the defaults
+func NewBroadcaster[T comparable](service IService, channel string, target *broadcaster.Generic[T], codec ICodec[T], options *BroadcasterOptions) (*Broadcaster[T], error) {
+	originBytes := make([]byte, 16)
+
+	_, err := rand.Read(originBytes)
+	if err != nil {
+		return nil, fmt.Errorf("can't generate origin id: %s", err)
+	}
+
+	b := &Broadcaster[T]{
+		service: service,
+		channel: channel,
+		target:  target,
+		codec:   codec,
+		origin:  hex.EncodeToString(originBytes),
+		log:     logger.GetLogger(fmt.Sprintf("service.%s.broadcaster", ServiceName)),
+	}
+
+	if options != nil {
+		b.options = *options
+	}
+
+	if b.options.Key == "" {
+		b.options.Key = channel
+	}
+
+	if b.options.ReconnectMinDelay <= 0 {
+		b.options.ReconnectMinDelay = 500 * time.Millisecond
+	}
+
+	if b.options.ReconnectMaxDelay <= 0 {
+		b.options.ReconnectMaxDelay = 30 * time.Second
+	}
+
+	if b.options.ReconnectMaxDelay < b.options.ReconnectMinDelay {
+		b.options.ReconnectMaxDelay = b.options.ReconnectMinDelay
+	}
+
+	return b, nil
+}
@@ -0,0 +1,247 @@
+package gousuredis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/indece-official/go-gousu/v2/gousu/broadcaster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSubscription struct{}
+
+func (s *testSubscription) Subscribe(channel ...interface{}) error   { return nil }
+func (s *testSubscription) Unsubscribe(channel ...interface{}) error { return nil }
+func (s *testSubscription) Close() error                             { return nil }
+
+func TestBroadcaster(t *testing.T) {
+	service := NewMockService()
+
+	mutex := sync.Mutex{}
+	published := [][]byte{}
+	stored := map[string][]byte{
+		"flags:stop": []byte("true"),
+	}
+	subscriptions := make(chan chan Message, 2)
+
+	service.GetFunc = func(key string) ([]byte, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		data, ok := stored[key]
+		if !ok {
+			return nil, ErrNil
+		}
+
+		return data, nil
+	}
+	service.SetFunc = func(key string, data []byte) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		stored[key] = data
+
+		return nil
+	}
+	service.PublishFunc = func(channel string, data []byte) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		published = append(published, data)
+
+		return nil
+	}
+	service.SubscribeFunc = func(channels []string) (chan Message, ISubscription, error) {
+		messages := make(chan Message)
+		subscriptions <- messages
+
+		return messages, &testSubscription{}, nil
+	}
+
+	target := broadcaster.NewBool(false)
+
+	b, err := NewBroadcaster[bool](service, "flags:stop", target, JSONCodec[bool]{}, &BroadcasterOptions{
+		ReconnectMinDelay: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, b.Start())
+	defer b.Stop()
+
+	// Bootstrapped from the key
+	assert.True(t, target.Value())
+
+	messages := <-subscriptions
+
+	// Remote values are applied, but not published again
+	remote, err := json.Marshal(&broadcasterEnvelope{Origin: "other", Data: []byte("false")})
+	require.NoError(t, err)
+
+	messages <- Message{Channel: "flags:stop", Data: remote}
+
+	_, err = target.WaitFor(testContext(t), func(val bool) bool { return !val })
+	require.NoError(t, err)
+
+	// Local values are stored and published
+	target.Next(true)
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(published) == 1
+	}, time.Second, time.Millisecond)
+
+	envelope := &broadcasterEnvelope{}
+	require.NoError(t, json.Unmarshal(published[0], envelope))
+	assert.Equal(t, b.origin, envelope.Origin)
+	assert.Equal(t, []byte("true"), envelope.Data)
+
+	// Reconnects after an error and bootstraps again
+	mutex.Lock()
+	stored["flags:stop"] = []byte("false")
+	mutex.Unlock()
+
+	messages <- Message{Error: fmt.Errorf("connection lost")}
+
+	<-subscriptions
+
+	_, err = target.WaitFor(testContext(t), func(val bool) bool { return !val })
+	require.NoError(t, err)
+
+	mutex.Lock()
+	assert.Len(t, published, 1)
+	mutex.Unlock()
+}
+
+func TestBroadcasterStalledPublish(t *testing.T) {
+	service := NewMockService()
+
+	release := make(chan struct{})
+
+	service.GetFunc = func(key string) ([]byte, error) {
+		return nil, ErrNil
+	}
+	service.SetFunc = func(key string, data []byte) error {
+		return nil
+	}
+	service.PublishFunc = func(channel string, data []byte) error {
+		<-release
+
+		return nil
+	}
+	service.SubscribeFunc = func(channels []string) (chan Message, ISubscription, error) {
+		return make(chan Message), &testSubscription{}, nil
+	}
+
+	target := broadcaster.NewInt(0)
+
+	b, err := NewBroadcaster[int](service, "counter", target, JSONCodec[int]{}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Start())
+
+	done := make(chan struct{})
+
+	go func() {
+		for i := 1; i <= 100; i++ {
+			target.Next(i)
+		}
+
+		close(done)
+	}()
+
+	// A stalled redis doesn't block the local broadcaster
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Next was blocked by the stalled redis publish")
+	}
+
+	assert.Equal(t, 100, target.Value())
+
+	close(release)
+	require.NoError(t, b.Stop())
+}
+
+func TestBroadcasterTargetClosed(t *testing.T) {
+	service := NewMockService()
+
+	mutex := sync.Mutex{}
+	published := 0
+
+	service.GetFunc = func(key string) ([]byte, error) {
+		return nil, ErrNil
+	}
+	service.SetFunc = func(key string, data []byte) error {
+		return nil
+	}
+	service.PublishFunc = func(channel string, data []byte) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		published++
+
+		return nil
+	}
+	service.SubscribeFunc = func(channels []string) (chan Message, ISubscription, error) {
+		return make(chan Message), &testSubscription{}, nil
+	}
+
+	target := broadcaster.NewInt(0)
+
+	b, err := NewBroadcaster[int](service, "counter", target, JSONCodec[int]{}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Start())
+
+	target.Next(1)
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return published == 1
+	}, time.Second, time.Millisecond)
+
+	// Closing the target stops forwarding
+	target.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	mutex.Lock()
+	assert.Equal(t, 1, published)
+	mutex.Unlock()
+
+	require.NoError(t, b.Stop())
+}
+
+func TestErrorCodec(t *testing.T) {
+	codec := ErrorCodec{}
+
+	data, err := codec.Encode(fmt.Errorf("failed"))
+	require.NoError(t, err)
+
+	value, err := codec.Decode(data)
+	require.NoError(t, err)
+	assert.EqualError(t, value, "failed")
+
+	data, err = codec.Encode(nil)
+	require.NoError(t, err)
+
+	value, err = codec.Decode(data)
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	return ctx
+}
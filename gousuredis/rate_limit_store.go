@@ -0,0 +1,123 @@
+package gousuredis
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// tokenBucketScript consumes a token from a bucket refilled continuously within the window
+//
+// KEYS[1] - key of the bucket hash
+// ARGV[1] - capacity of the bucket
+// ARGV[2] - window [ms]
+// ARGV[3] - current time [ms]
+//
+// Returns {allowed (0/1), remaining tokens, retry after [ms]}
+var tokenBucketScript = redis.NewScript(1, `
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local rate = capacity / window
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// slidingWindowLimitScript allows up to limit requests within the window
+//
+// KEYS[1] - key of the sorted set
+// ARGV[1] - limit
+// ARGV[2] - window [ms]
+// ARGV[3] - current time [ms]
+// ARGV[4] - unique member
+//
+// Returns {allowed (0/1), remaining requests, retry after [ms]}
+var slidingWindowLimitScript = redis.NewScript(1, `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	redis.call('PEXPIRE', KEYS[1], window)
+	return {1, limit - count - 1, 0}
+end
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return {0, 0, tonumber(oldest[2]) + window - now}
+`)
+
+// RateLimitStore stores rate limits in redis, so they are shared between
+// multiple replicas
+//
+// It implements gousuchi.IRateLimitStore
+type RateLimitStore struct {
+	service IScriptService
+	prefix  string
+}
+
+func (s *RateLimitStore) eval(script *redis.Script, keysAndArgs ...interface{}) (bool, int, time.Duration, error) {
+	values, err := redis.Int64s(s.service.Eval(script, keysAndArgs...))
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("can't check rate limit in redis: %s", err)
+	}
+
+	if len(values) != 3 {
+		return false, 0, 0, fmt.Errorf("invalid rate limit result from redis: %v", values)
+	}
+
+	return values[0] == 1, int(values[1]), time.Duration(values[2]) * time.Millisecond, nil
+}
+
+// TokenBucket consumes a token from a bucket holding up to limit tokens, which is
+// refilled completely within the window
+func (s *RateLimitStore) TokenBucket(key string, limit int, window time.Duration, now time.Time) (bool, int, time.Duration, error) {
+	return s.eval(
+		tokenBucketScript,
+		s.prefix+key,
+		limit,
+		window.Milliseconds(),
+		now.UnixMilli(),
+	)
+}
+
+// SlidingWindow allows up to limit requests within the window before now
+func (s *RateLimitStore) SlidingWindow(key string, limit int, window time.Duration, now time.Time) (bool, int, time.Duration, error) {
+	return s.eval(
+		slidingWindowLimitScript,
+		s.prefix+key,
+		limit,
+		window.Milliseconds(),
+		now.UnixMilli(),
+		fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int63()),
+	)
+}
+
+// NewRateLimitStore creates a new initialized instance of RateLimitStore
+//
+// All keys are prefixed with the given prefix (e.g. "ratelimit:")
+func NewRateLimitStore(service IScriptService, prefix string) *RateLimitStore {
+	return &RateLimitStore{
+		service: service,
+		prefix:  prefix,
+	}
+}
@@ -0,0 +1,41 @@
+package gousuredis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitStore(t *testing.T) {
+	service := NewMockService()
+
+	evalKeys := []interface{}{}
+	service.EvalFunc = func(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+		evalKeys = append(evalKeys, keysAndArgs[0])
+
+		if script == slidingWindowLimitScript {
+			return []interface{}{int64(0), int64(0), int64(1500)}, nil
+		}
+
+		return []interface{}{int64(1), int64(4), int64(0)}, nil
+	}
+
+	store := NewRateLimitStore(service, "ratelimit:")
+
+	allowed, remaining, retryAfter, err := store.TokenBucket("login:ip:127.0.0.1", 5, time.Minute, time.Now())
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 4, remaining)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	allowed, remaining, retryAfter, err = store.SlidingWindow("login:ip:127.0.0.1", 5, time.Minute, time.Now())
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, remaining)
+	assert.Equal(t, 1500*time.Millisecond, retryAfter)
+
+	assert.Equal(t, []interface{}{"ratelimit:login:ip:127.0.0.1", "ratelimit:login:ip:127.0.0.1"}, evalKeys)
+}
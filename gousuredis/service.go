@@ -71,6 +71,15 @@ type IService interface {
 	XTrim(key string, params *XTrimParams) (int64, error)
 }
 
+// IScriptService extends IService by the execution of lua scripts
+//
+// Separated from IService so existing implementations of IService stay valid
+type IScriptService interface {
+	IService
+
+	Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error)
+}
+
 // Service provides a service for basic redis client functionality
 //
 // Used flags:
@@ -83,7 +92,7 @@ type Service struct {
 	redsyncClient *redsync.Redsync
 }
 
-var _ IService = (*Service)(nil)
+var _ IScriptService = (*Service)(nil)
 
 func (s *Service) createPool(addr string, opts ...redis.DialOption) (*redis.Pool, error) {
 	return &redis.Pool{
@@ -744,6 +753,17 @@ func (s *Service) Publish(channel string, data []byte) error {
 	return err
 }
 
+// Eval executes a lua script (using EVALSHA with a fallback to EVAL)
+func (s *Service) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	conn, err := s.openConn(true)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to redis: %s", err)
+	}
+	defer conn.Close()
+
+	return script.Do(conn, keysAndArgs...)
+}
+
 // NewMutex creates a new redsync mutex
 func (s *Service) NewMutex(name string, options ...redsync.Option) *redsync.Mutex {
 	return s.redsyncClient.NewMutex(name, options...)
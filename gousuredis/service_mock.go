@@ -40,6 +40,7 @@ type MockService struct {
 	LLenFunc                func(key string) (int, error)
 	SubscribeFunc           func(channels []string) (chan Message, ISubscription, error)
 	PublishFunc             func(channel string, data []byte) error
+	EvalFunc                func(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error)
 	XAddFunc                func(key string, data map[string]string) (string, error)
 	XGroupCreateFunc        func(groupName string, key string, offset XGroupCreateOffset, mkStream bool, ignoreBusy bool) error
 	XGroupDestroyFunc       func(groupName string, key string) error
@@ -74,6 +75,7 @@ type MockService struct {
 	LLenFuncCalled          int
 	SubscribeFuncCalled     int
 	PublishFuncCalled       int
+	EvalFuncCalled          int
 	XAddFuncCalled          int
 	XGroupCreateFuncCalled  int
 	XGroupDestroyFuncCalled int
@@ -83,8 +85,8 @@ type MockService struct {
 	XTrimFuncCalled         int
 }
 
-// MockService implements IService
-var _ (IService) = (*MockService)(nil)
+// MockService implements IScriptService
+var _ (IScriptService) = (*MockService)(nil)
 
 // NewMutex calls NewMutexFunc and increases NewMutexFuncCalled
 func (s *MockService) NewMutex(name string, options ...redsync.Option) *redsync.Mutex {
@@ -275,6 +277,13 @@ func (s *MockService) Publish(channel string, data []byte) error {
 	return s.PublishFunc(channel, data)
 }
 
+// Eval calls EvalFunc and increases EvalFuncCalled
+func (s *MockService) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	s.EvalFuncCalled++
+
+	return s.EvalFunc(script, keysAndArgs...)
+}
+
 // XAdd calls XAddFunc and increases XAddFuncCalled
 func (s *MockService) XAdd(key string, data map[string]string) (string, error) {
 	s.XAddFuncCalled++
@@ -411,6 +420,9 @@ func NewMockService() *MockService {
 		PublishFunc: func(channel string, data []byte) error {
 			return nil
 		},
+		EvalFunc: func(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
 		XAddFunc: func(key string, data map[string]string) (string, error) {
 			return "", nil
 		},
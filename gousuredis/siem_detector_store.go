@@ -0,0 +1,64 @@
+package gousuredis
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/indece-official/go-gousu/v2/gousu/siem"
+)
+
+// slidingWindowScript adds a member to a sorted set scored by time, removes
+// all members outside of the window and returns the number of remaining members
+//
+// KEYS[1] - key of the sorted set
+// ARGV[1] - current time [ms]
+// ARGV[2] - window [ms]
+// ARGV[3] - unique member
+var slidingWindowScript = redis.NewScript(1, `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', tonumber(ARGV[1]) - tonumber(ARGV[2]))
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return redis.call('ZCARD', KEYS[1])
+`)
+
+// SiemDetectorStore is a siem.IDetectorStore sharing the failure counts of
+// siem.Detector between multiple replicas via redis
+type SiemDetectorStore struct {
+	service IScriptService
+	prefix  string
+}
+
+var _ siem.IDetectorStore = (*SiemDetectorStore)(nil)
+
+// Add records a failure for the key and returns the number of failures within the window
+func (s *SiemDetectorStore) Add(key string, at time.Time, window time.Duration) (int, error) {
+	count, err := redis.Int(s.service.Eval(
+		slidingWindowScript,
+		s.prefix+key,
+		at.UnixMilli(),
+		window.Milliseconds(),
+		fmt.Sprintf("%d-%d", at.UnixNano(), rand.Int63()),
+	))
+	if err != nil {
+		return 0, fmt.Errorf("can't add failure to redis: %s", err)
+	}
+
+	return count, nil
+}
+
+// Reset removes all failures recorded for the key
+func (s *SiemDetectorStore) Reset(key string) error {
+	return s.service.Del(s.prefix + key)
+}
+
+// NewSiemDetectorStore creates a new initialized instance of SiemDetectorStore
+//
+// All keys are prefixed with the given prefix (e.g. "siem:detector:")
+func NewSiemDetectorStore(service IScriptService, prefix string) *SiemDetectorStore {
+	return &SiemDetectorStore{
+		service: service,
+		prefix:  prefix,
+	}
+}
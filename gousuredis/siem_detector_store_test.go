@@ -0,0 +1,42 @@
+package gousuredis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiemDetectorStore(t *testing.T) {
+	service := NewMockService()
+
+	evalKeys := []interface{}{}
+	service.EvalFunc = func(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+		evalKeys = append(evalKeys, keysAndArgs[0])
+
+		return int64(len(evalKeys)), nil
+	}
+
+	deletedKeys := []string{}
+	service.DelFunc = func(key string) error {
+		deletedKeys = append(deletedKeys, key)
+
+		return nil
+	}
+
+	store := NewSiemDetectorStore(service, "siem:")
+
+	count, err := store.Add("login_failed:user:test", time.Now(), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = store.Add("login_failed:user:test", time.Now(), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, store.Reset("login_failed:user:test"))
+
+	assert.Equal(t, []interface{}{"siem:login_failed:user:test", "siem:login_failed:user:test"}, evalKeys)
+	assert.Equal(t, []string{"siem:login_failed:user:test"}, deletedKeys)
+}